@@ -0,0 +1,533 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go-file-prompt/extract"
+)
+
+// version is the build version, set with -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
+// ClipboardEnvVar disables clipboard writes when set to "off".
+const ClipboardEnvVar = "GFE_CLIPBOARD"
+
+// summarizeArgs renders saved arguments on one line, shortened for display in the menu.
+func summarizeArgs(args []string) string {
+	const maxLen = 60
+	summary := []rune(strings.Join(args, " "))
+	if len(summary) > maxLen {
+		return string(summary[:maxLen-3]) + "..."
+	}
+	return string(summary)
+}
+
+// promptSavedConfig lists the saved configurations and reads lines from in until
+// one matches an entry by number or name. It returns an empty name if the input
+// ends before a valid choice is made.
+func promptSavedConfig(in io.Reader, savedConfigs map[string][]string) (string, error) {
+	// List saved names in a stable order so the numbers do not change between runs
+	var savedNames []string
+	for name := range savedConfigs {
+		savedNames = append(savedNames, name)
+	}
+	sort.Strings(savedNames)
+
+	fmt.Println("Select a saved configuration:")
+	for i, name := range savedNames {
+		fmt.Printf("%d. %s  (%s)\n", i+1, name, summarizeArgs(savedConfigs[name]))
+	}
+
+	reader := bufio.NewReader(in)
+	for {
+		fmt.Print("Enter the number or name of the configuration to load: ")
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", ioError("Failed to read selection: %v", err)
+		}
+		choice := strings.TrimSpace(line)
+		if err == io.EOF && choice == "" {
+			fmt.Println()
+			return "", nil
+		}
+		if choice == "" {
+			continue
+		}
+		name, err := matchSavedName(savedNames, choice)
+		if err == nil {
+			return name, nil
+		}
+		fmt.Printf("Invalid choice %q: %v. Enter a number from 1 to %d or a configuration name.\n", choice, err, len(savedNames))
+	}
+}
+
+// matchSavedName resolves a menu choice, given as a 1-based index or a name
+// matched by extract.MatchSavedName, to a saved name.
+func matchSavedName(savedNames []string, choice string) (string, error) {
+	if index, err := strconv.Atoi(choice); err == nil {
+		if index >= 1 && index <= len(savedNames) {
+			return savedNames[index-1], nil
+		}
+		return "", errors.New("no configuration has that number")
+	}
+	return extract.MatchSavedName(savedNames, choice)
+}
+
+// maxPickerFiles caps how many files promptFiles lists.
+const maxPickerFiles = 200
+
+// promptFiles lists files by number and reads one line of numbers and ranges
+// from in, such as "1 3 5-7", returning the chosen files in list order. It
+// returns no files if the line is empty or the input ends.
+func promptFiles(in io.Reader, files []string) ([]string, error) {
+	fmt.Println("Select files to extract:")
+	shown := files[:min(len(files), maxPickerFiles)]
+	for i, file := range shown {
+		fmt.Printf("%d. %s\n", i+1, file)
+	}
+	if len(shown) < len(files) {
+		fmt.Printf("(showing the first %d of %d files; pass -files to choose others)\n", len(shown), len(files))
+	}
+
+	reader := bufio.NewReader(in)
+	for {
+		fmt.Print("Enter file numbers or ranges (e.g. 1 3 5-7), or press Enter to cancel: ")
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, ioError("Failed to read selection: %v", err)
+		}
+		choice := strings.TrimSpace(line)
+		if choice == "" {
+			if err == io.EOF {
+				fmt.Println()
+			}
+			return nil, nil
+		}
+		indexes, ok := parseFileSelection(choice, len(shown))
+		if ok {
+			selected := make([]string, len(indexes))
+			for i, index := range indexes {
+				selected[i] = shown[index]
+			}
+			return selected, nil
+		}
+		fmt.Printf("Invalid selection %q, enter numbers from 1 to %d.\n", choice, len(shown))
+		if err == io.EOF {
+			return nil, nil
+		}
+	}
+}
+
+// parseFileSelection parses numbers and ranges separated by spaces or commas
+// into sorted, unique 0-based indexes below count.
+func parseFileSelection(choice string, count int) ([]int, bool) {
+	seen := make(map[int]bool)
+	for _, field := range strings.FieldsFunc(choice, func(r rune) bool { return r == ' ' || r == ',' }) {
+		first, last, isRange := strings.Cut(field, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, false
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(last); err != nil {
+				return nil, false
+			}
+		}
+		if start < 1 || end > count || start > end {
+			return nil, false
+		}
+		for n := start; n <= end; n++ {
+			seen[n-1] = true
+		}
+	}
+	if len(seen) == 0 {
+		return nil, false
+	}
+	return slices.Sorted(maps.Keys(seen)), true
+}
+
+// Exit codes returned by the command.
+const (
+	ExitUsage = 1 // Invalid arguments or selection
+	ExitIO    = 2 // Reading or writing files and configuration failed
+	ExitExec  = 3 // An executable failed or timed out
+)
+
+// exitError pairs an error with the exit code the process should return.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// usageError returns an error that exits with ExitUsage.
+func usageError(format string, args ...any) error {
+	return &exitError{code: ExitUsage, err: fmt.Errorf(format, args...)}
+}
+
+// ioError returns an error that exits with ExitIO.
+func ioError(format string, args ...any) error {
+	return &exitError{code: ExitIO, err: fmt.Errorf(format, args...)}
+}
+
+// extractError returns an error for a failed extraction that exits with
+// ExitExec if an executable failed and ExitIO otherwise.
+func extractError(err error) error {
+	var execErr *extract.ExecError
+	if errors.As(err, &execErr) {
+		return &exitError{code: ExitExec, err: fmt.Errorf("Failed to process files: %w", err)}
+	}
+	return ioError("Failed to process files: %v", err)
+}
+
+// exitCode returns the process exit code for an error returned by run.
+func exitCode(err error) int {
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return ExitUsage
+}
+
+func main() {
+	if err := run(os.Args[1:], nil); err != nil {
+		log.Print(err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// run executes the command with the given arguments and returns any error.
+// The output is written to clip, or to the clipboard selected by the flags
+// when clip is nil.
+func run(args []string, clip extract.Clipboard) error {
+	// Informational flags short-circuit normal processing
+	infoFlag := extract.FindInfoFlag(args)
+	switch infoFlag {
+	case "-help":
+		extract.PrintUsage(os.Stdout)
+		return nil
+	case "-version":
+		fmt.Printf("go-file-extract %s\n", version)
+		return nil
+	}
+
+	// Initialize the application
+	configPath, err := extract.ResolveConfigPath(args)
+	if err != nil {
+		return ioError("Failed to get user home directory: %v", err)
+	}
+
+	// Write an example config before loading the current one, so -init -force can replace a broken file
+	if infoFlag == "-init" {
+		opts, err := extract.ParseArguments(args, extract.DefaultDelimiter, extract.Defaults{})
+		if err != nil {
+			return usageError("Failed to parse arguments: %v", err)
+		}
+		if err := extract.InitConfig(configPath, opts.Force); err != nil {
+			return ioError("Failed to initialize config: %v", err)
+		}
+		fmt.Printf("Example config written to %s\n", configPath)
+		return nil
+	}
+
+	app, err := extract.NewApp(configPath)
+	if err != nil {
+		return ioError("Failed to initialize application: %v", err)
+	}
+	workDir, err := os.Getwd()
+	if err != nil {
+		return ioError("Failed to get current directory: %v", err)
+	}
+	if err := app.LoadProjectConfig(workDir); err != nil {
+		return ioError("Failed to load project config: %v", err)
+	}
+
+	// Handle interactive selection if no arguments are provided
+	if len(args) == 0 {
+		currentDir, err := os.Getwd()
+		if err != nil {
+			return ioError("Failed to get current directory: %v", err)
+		}
+
+		// Load all saved names for the current folder, offering a file picker when there are none
+		savedConfigs := app.SavedConfigs(currentDir)
+		if len(savedConfigs) == 0 {
+			fmt.Printf("No saved configurations found for folder '%s'. Run with -help to see usage.\n", currentDir)
+			files, err := app.ListFiles(currentDir)
+			if err != nil {
+				return ioError("Failed to list files: %v", err)
+			}
+			if len(files) == 0 {
+				return nil
+			}
+			selected, err := promptFiles(os.Stdin, files)
+			if err != nil {
+				return err
+			}
+			if len(selected) == 0 {
+				return nil
+			}
+			return run(append([]string{"-files"}, selected...), clip)
+		}
+
+		selectedName, err := promptSavedConfig(os.Stdin, savedConfigs)
+		if err != nil {
+			return err
+		}
+		if selectedName == "" {
+			// Input was closed (Ctrl-D) without a choice
+			return nil
+		}
+
+		// Load the selected saved configuration
+		savedArgs, err := app.SavedConfig(currentDir, selectedName)
+		if err != nil {
+			return usageError("Failed to load saved configuration: %v", err)
+		}
+
+		// Reparse arguments from saved configuration
+		args = savedArgs
+	}
+
+	// Parse arguments
+	opts, err := extract.ParseArguments(args, app.DefaultDelimiter(), app.Defaults())
+	if err != nil {
+		return usageError("Failed to parse arguments: %v", err)
+	}
+
+	// Print a shell completion script
+	if opts.Completion != "" {
+		return extract.WriteCompletion(os.Stdout, opts.Completion)
+	}
+
+	// List saved names, used by the completion scripts to complete -by-name
+	if opts.ListSaved {
+		currentDir, err := os.Getwd()
+		if err != nil {
+			return ioError("Failed to get current directory: %v", err)
+		}
+		names := slices.Sorted(maps.Keys(app.SavedConfigs(currentDir)))
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+	}
+
+	// Decode output produced by -compress instead of extracting files
+	if opts.Decompress {
+		encoded, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return ioError("Failed to read stdin: %v", err)
+		}
+		text, err := extract.DecompressOutput(string(encoded))
+		if err != nil {
+			return usageError("Failed to decompress input: %v", err)
+		}
+		fmt.Print(text)
+		return nil
+	}
+
+	// Replay saved arguments if -by-name is provided; several names are replayed in
+	// order, so later configurations win for single-valued flags and list flags
+	// such as -files are combined. The remaining arguments are applied on top.
+	if len(opts.ByName) > 0 {
+		currentDir, err := os.Getwd()
+		if err != nil {
+			return ioError("Failed to get current directory: %v", err)
+		}
+		savedConfigs := app.SavedConfigs(currentDir)
+		savedNames := slices.Sorted(maps.Keys(savedConfigs))
+		var savedArgs []string
+		for _, query := range opts.ByName {
+			name, err := extract.MatchSavedName(savedNames, query)
+			if err != nil {
+				return usageError("Failed to load saved configuration: %v", err)
+			}
+			savedArgs = append(savedArgs, savedConfigs[name]...)
+		}
+		args = slices.Concat(savedArgs, extract.FilterOutFlags(args, "-by-name"))
+		opts, err = extract.ParseArguments(args, app.DefaultDelimiter(), app.Defaults())
+		if err != nil {
+			return usageError("Failed to parse arguments: %v", err)
+		}
+	}
+
+	// Share saved configurations between folders and machines
+	if opts.ExportConfig != "" || opts.ImportConfig != "" {
+		currentDir, err := os.Getwd()
+		if err != nil {
+			return ioError("Failed to get current directory: %v", err)
+		}
+		if opts.ExportConfig != "" {
+			count, err := app.ExportConfigs(currentDir, opts.ExportConfig)
+			if err != nil {
+				return ioError("Failed to export configurations: %v", err)
+			}
+			if !opts.Quiet {
+				fmt.Printf("Exported %d configuration(s) to %s\n", count, opts.ExportConfig)
+			}
+		}
+		if opts.ImportConfig != "" {
+			app.NoBackup = opts.NoBackup
+			count, err := app.ImportConfigs(currentDir, opts.ImportConfig, opts.Force)
+			if err != nil {
+				return ioError("Failed to import configurations: %v", err)
+			}
+			if !opts.Quiet {
+				fmt.Printf("Imported %d configuration(s) for folder '%s'\n", count, currentDir)
+			}
+		}
+		return nil
+	}
+
+	// Print the configuration after every layer has been applied
+	if opts.PrintConfig {
+		data, err := json.MarshalIndent(app.EffectiveConfig(*opts), "", "  ")
+		if err != nil {
+			return ioError("Failed to encode configuration: %v", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	app.NoBackup = opts.NoBackup
+
+	// Save configuration if -name is provided
+	if opts.SaveName != "" {
+		currentDir, err := os.Getwd()
+		if err != nil {
+			return ioError("Failed to get current directory: %v", err)
+		}
+		if err := app.SaveCurrentConfig(currentDir, opts.SaveName, args); err != nil {
+			return ioError("Failed to save configuration: %v", err)
+		}
+		if !opts.Quiet {
+			fmt.Printf("Arguments saved for name '%s' in folder '%s'\n", opts.SaveName, currentDir)
+		}
+		return nil
+	}
+
+	// Save configuration for every folder if -save-global is provided
+	if opts.SaveGlobalName != "" {
+		if err := app.SaveCurrentConfig(extract.GlobalFolderKey, opts.SaveGlobalName, args); err != nil {
+			return ioError("Failed to save configuration: %v", err)
+		}
+		if !opts.Quiet {
+			fmt.Printf("Arguments saved globally for name '%s'\n", opts.SaveGlobalName)
+		}
+		return nil
+	}
+
+	// Remove cached files, then extract as usual if there is anything to extract
+	if opts.ClearCache {
+		if err := extract.ClearCache(); err != nil {
+			return ioError("Failed to clear cache: %v", err)
+		}
+		if !opts.Quiet {
+			fmt.Fprintln(os.Stderr, "Cache has been cleared.")
+		}
+		if len(opts.Files) == 0 && opts.Preset == "" && len(opts.Diff) == 0 {
+			return nil
+		}
+	}
+
+	// Ensure files are provided
+	if len(opts.Files) == 0 && opts.Preset == "" && len(opts.Diff) == 0 {
+		return usageError("No files specified. Please provide at least one file.")
+	}
+
+	// Generate output
+	// Stop extracting on Ctrl-C, including any running executable
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Stream plain stdout output file by file instead of holding all of it in memory
+	toStdout := opts.NoClipboard || os.Getenv(ClipboardEnvVar) == "off"
+	if toStdout && !opts.Count && !opts.Compress && opts.ChunkBytes == 0 {
+		stdout := bufio.NewWriter(os.Stdout)
+		err := app.ExtractTo(ctx, stdout, *opts)
+		if flushErr := stdout.Flush(); err == nil && flushErr != nil {
+			err = fmt.Errorf("failed to write output: %v", flushErr)
+		}
+		if err != nil {
+			return extractError(err)
+		}
+		if !opts.Quiet {
+			fmt.Fprintln(os.Stderr, "Output has been written to stdout; the clipboard was not modified.")
+		}
+		return nil
+	}
+
+	output, err := app.ExtractContext(ctx, *opts)
+	if err != nil {
+		return extractError(err)
+	}
+	// Totals from -count always go to stdout
+	if opts.Count {
+		fmt.Print(output)
+		return nil
+	}
+	if opts.Compress {
+		output, err = extract.CompressOutput(output)
+		if err != nil {
+			return ioError("Failed to prepare output: %v", err)
+		}
+	}
+
+	// Split the output into numbered files instead of touching the clipboard if requested
+	if opts.ChunkBytes > 0 {
+		chunks := extract.SplitChunks(output, extract.ChunkDelimiter(opts), opts.ChunkBytes)
+		for i, chunk := range chunks {
+			path := fmt.Sprintf("%s.%d.txt", opts.ChunkPrefix, i+1)
+			if err := os.WriteFile(path, []byte(chunk), 0644); err != nil {
+				return ioError("Failed to write chunk: %v", err)
+			}
+			if !opts.Quiet {
+				fmt.Printf("Part %d of %d written to %s (%d bytes)\n", i+1, len(chunks), path, len(chunk))
+			}
+		}
+		return nil
+	}
+
+	// Print the output instead of touching the clipboard if requested
+	if toStdout {
+		fmt.Print(output)
+		if !opts.Quiet {
+			fmt.Fprintln(os.Stderr, "Output has been written to stdout; the clipboard was not modified.")
+		}
+		return nil
+	}
+
+	// Copy output to clipboard, falling back to stdout so the work is not lost
+	if clip == nil {
+		clip = extract.NewClipboard(opts)
+	}
+	if err := clip.Write(output); err != nil {
+		fmt.Print(output)
+		if !opts.Quiet {
+			log.Printf("Warning: failed to copy output to clipboard: %v", err)
+			fmt.Fprintln(os.Stderr, "Output has been written to stdout instead.")
+		}
+		return nil
+	}
+	if !opts.Quiet {
+		fmt.Println("Output has been copied to the clipboard.")
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-file-prompt/extract"
+)
+
+// failingClipboard rejects every write, like a system without clipboard tools.
+type failingClipboard struct{}
+
+func (failingClipboard) Write(string) error { return errors.New("no clipboard") }
+
+func TestRunWritesClipboard(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(extract.ConfigEnvVar, filepath.Join(dir, "config.json"))
+	t.Setenv(ClipboardEnvVar, "")
+	path := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(path, []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"default", []string{"-files", path}, "a.go\n```go\npackage a\n\n```\n======\n"},
+		{"no code fences", []string{"-files", path, "-wrap-code", "false"}, "a.go\npackage a\n\n======\n"},
+		{"no clipboard", []string{"-files", path, "-no-clipboard", "-quiet"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clip := &extract.MemoryClipboard{}
+			if err := run(append([]string{"-base-dir", dir}, tt.args...), clip); err != nil {
+				t.Fatal(err)
+			}
+			if clip.Text != tt.want {
+				t.Errorf("clipboard = %q, want %q", clip.Text, tt.want)
+			}
+		})
+	}
+
+	t.Run("failing clipboard", func(t *testing.T) {
+		// The output falls back to stdout instead of failing the run
+		if err := run([]string{"-base-dir", dir, "-files", path, "-quiet"}, failingClipboard{}); err != nil {
+			t.Errorf("run with a failing clipboard = %v, want nil", err)
+		}
+	})
+}
+
+func TestRunMergesSavedConfigs(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(extract.ConfigEnvVar, filepath.Join(dir, "config.json"))
+	t.Setenv(ClipboardEnvVar, "")
+	for name, content := range map[string]string{"a.go": "package a\n", "b.go": "package b\n", "c.md": "# c\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, b, c := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go"), filepath.Join(dir, "c.md")
+
+	saved := map[string][]string{
+		"go-files":   {"-files", a, b, "-delimiter", "--go--"},
+		"docs-files": {"-files", c, a, "-delimiter", "--docs--"},
+	}
+	for name, args := range saved {
+		if err := run(append([]string{"-name", name, "-quiet", "-base-dir", dir}, args...), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "one name",
+			args: []string{"-by-name", "go-files"},
+			want: "a.go\n```go\npackage a\n\n```\n--go--\nb.go\n```go\npackage b\n\n```\n--go--\n",
+		},
+		{
+			name: "comma-separated names",
+			args: []string{"-by-name", "go-files,docs-files"},
+			want: "a.go\n```go\npackage a\n\n```\n--docs--\nb.go\n```go\npackage b\n\n```\n--docs--\n" +
+				"c.md\n```markdown\n# c\n\n```\n--docs--\n",
+		},
+		{
+			name: "repeated flag",
+			args: []string{"-by-name", "docs-files", "-by-name", "go-files"},
+			want: "c.md\n```markdown\n# c\n\n```\n--go--\na.go\n```go\npackage a\n\n```\n--go--\n" +
+				"b.go\n```go\npackage b\n\n```\n--go--\n",
+		},
+		{
+			name: "command line wins",
+			args: []string{"-by-name", "go-files,docs-files", "-delimiter", "--cli--"},
+			want: "a.go\n```go\npackage a\n\n```\n--cli--\nb.go\n```go\npackage b\n\n```\n--cli--\n" +
+				"c.md\n```markdown\n# c\n\n```\n--cli--\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clip := &extract.MemoryClipboard{}
+			if err := run(append(tt.args, "-quiet"), clip); err != nil {
+				t.Fatal(err)
+			}
+			if clip.Text != tt.want {
+				t.Errorf("clipboard = %q, want %q", clip.Text, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,372 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Constants for default values
+const DefaultDelimiter = "======"
+
+// ConfigEnvVar overrides the config file path when -config is not passed.
+const ConfigEnvVar = "GFE_CONFIG"
+
+// GlobalFolderKey is the Folders key holding saved configurations available in every folder.
+const GlobalFolderKey = "*"
+
+// AppName names the directory holding the config file under ~/.config.
+const AppName = "go-file-extract"
+
+// Config represents the application's configuration.
+type Config struct {
+	Folders             map[string]FolderConfig `json:"folders"`
+	FileTypeExecutables map[string]string       `json:"file_type_executables"`        // Map of file extensions to executables
+	DefaultDelimiter    string                  `json:"default_delimiter,omitempty"`  // Delimiter used when -delimiter is not passed
+	Defaults            *Defaults               `json:"defaults,omitempty"`           // Flag defaults applied before command-line arguments
+	RedactPatterns      []string                `json:"redact_patterns,omitempty"`    // Extra secret regexes for -redact
+	SecretFiles         []string                `json:"secret_files,omitempty"`       // Replaces DefaultSecretFiles when set
+	GeneratedPatterns   []string                `json:"generated_patterns,omitempty"` // Replaces DefaultGeneratedPatterns when set
+	Backup              *bool                   `json:"backup,omitempty"`             // Keep config.json.bak when saving; defaults to true
+}
+
+// Defaults holds per-user flag defaults; unset fields keep the built-in defaults.
+type Defaults struct {
+	WrapCode        *bool   `json:"wrap_code,omitempty"`
+	IgnoreGitIgnore *bool   `json:"ignore_gitignore,omitempty"`
+	Format          *string `json:"format,omitempty"`
+}
+
+// FolderConfig represents saved configurations for a folder.
+type FolderConfig struct {
+	SavedName map[string][]string `json:"saved_name"`
+}
+
+// App encapsulates the application's state and dependencies.
+//
+// Settings are layered: the global Config is overridden by the ProjectConfig
+// discovered from the working directory, which in turn is overridden by
+// command-line flags. Only the global Config is ever written back to disk.
+type App struct {
+	Config            Config
+	ConfigPath        string
+	ProjectConfig     *Config // Nil when no project config was found
+	ProjectConfigPath string
+	NoBackup          bool // Skip the backup of the previous config when saving
+
+	ignores *ignoreRules // Cached by ignoreRules
+}
+
+// NewApp initializes a new App instance.
+func NewApp(configPath string) (*App, error) {
+	app := &App{
+		Config: Config{
+			Folders:             make(map[string]FolderConfig),
+			FileTypeExecutables: make(map[string]string),
+		},
+		ConfigPath: configPath,
+	}
+	// Load the configuration file if it exists
+	if err := app.loadConfig(); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// loadConfig loads the configuration from the specified path.
+func (app *App) loadConfig() error {
+	err := readConfigFile(app.ConfigPath, &app.Config)
+	if os.IsNotExist(err) {
+		return nil // No config file exists yet
+	}
+	return err
+}
+
+// readConfigFile parses the JSON config file at path into config.
+// Errors from reading the file are returned unwrapped so callers can check os.IsNotExist.
+func readConfigFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return err
+		}
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+	// Reject unknown keys so a misspelled setting fails loudly instead of being ignored
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	if err := config.validate(); err != nil {
+		return fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// validate checks the settings that JSON decoding cannot.
+func (config *Config) validate() error {
+	for ext, executable := range config.FileTypeExecutables {
+		if strings.TrimSpace(executable) == "" {
+			return fmt.Errorf("file_type_executables: executable for %q is empty", ext)
+		}
+	}
+	if config.Defaults != nil && config.Defaults.Format != nil && !slices.Contains(formats, *config.Defaults.Format) {
+		return fmt.Errorf("defaults: invalid format %q. Expected one of %s", *config.Defaults.Format, strings.Join(formats, ", "))
+	}
+	if _, err := compileGeneratedPatterns(config.GeneratedPatterns); err != nil {
+		return fmt.Errorf("generated_patterns: %v", err)
+	}
+	return nil
+}
+
+// saveConfig saves the current configuration to the specified path.
+func (app *App) saveConfig() error {
+	data, err := json.MarshalIndent(app.Config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(app.ConfigPath), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+	if app.backupEnabled() {
+		if err := backupFile(app.ConfigPath, data); err != nil {
+			return fmt.Errorf("failed to back up config file: %v", err)
+		}
+	}
+	if err := writeFileAtomic(app.ConfigPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %v", err)
+	}
+	return nil
+}
+
+// BackupSuffix is appended to the config path to name the backup written before each save.
+const BackupSuffix = ".bak"
+
+// backupEnabled reports whether saveConfig keeps a backup of the previous config.
+func (app *App) backupEnabled() bool {
+	if app.NoBackup {
+		return false
+	}
+	return app.Config.Backup == nil || *app.Config.Backup
+}
+
+// backupFile copies the current content of path to path+BackupSuffix when it
+// differs from data, which is about to replace it. The backup therefore always
+// holds the last different version, even across repeated identical saves.
+func backupFile(path string, data []byte) error {
+	previous, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil // Nothing to back up yet
+	}
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(previous, data) {
+		return nil
+	}
+	return writeFileAtomic(path+BackupSuffix, previous, 0644)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so an interrupted write never leaves a truncated file behind.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once the rename succeeded
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// CreateTemp uses 0600; match the permissions os.WriteFile would have used
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// DefaultDelimiter returns the configured default delimiter, falling back to DefaultDelimiter.
+func (app *App) DefaultDelimiter() string {
+	if app.ProjectConfig != nil && app.ProjectConfig.DefaultDelimiter != "" {
+		return app.ProjectConfig.DefaultDelimiter
+	}
+	if app.Config.DefaultDelimiter != "" {
+		return app.Config.DefaultDelimiter
+	}
+	return DefaultDelimiter
+}
+
+// Defaults returns the configured flag defaults, or empty defaults if none are configured.
+// Fields set in the project config override the global ones.
+func (app *App) Defaults() Defaults {
+	var defaults Defaults
+	for _, config := range app.configLayers() {
+		if config.Defaults == nil {
+			continue
+		}
+		if config.Defaults.WrapCode != nil {
+			defaults.WrapCode = config.Defaults.WrapCode
+		}
+		if config.Defaults.IgnoreGitIgnore != nil {
+			defaults.IgnoreGitIgnore = config.Defaults.IgnoreGitIgnore
+		}
+		if config.Defaults.Format != nil {
+			defaults.Format = config.Defaults.Format
+		}
+	}
+	return defaults
+}
+
+// fileTypeExecutables returns the executables by file extension from the
+// global config. Project configs cannot set them; see validateProject.
+func (app *App) fileTypeExecutables() map[string]string {
+	executables := make(map[string]string)
+	for ext, cmd := range app.Config.FileTypeExecutables {
+		executables[ext] = cmd
+	}
+	return executables
+}
+
+// redactPatterns returns the extra -redact patterns from the global config.
+func (app *App) redactPatterns() []string {
+	return app.Config.RedactPatterns
+}
+
+// secretFiles returns the file patterns skipped without -include-secrets. A
+// secret_files list in the global config replaces the defaults; an empty list
+// disables the check.
+func (app *App) secretFiles() []string {
+	if app.Config.SecretFiles != nil {
+		return app.Config.SecretFiles
+	}
+	return DefaultSecretFiles
+}
+
+// generatedPatterns returns the markers -skip-generated looks for, from the
+// global config or the defaults.
+func (app *App) generatedPatterns() []string {
+	if app.Config.GeneratedPatterns != nil {
+		return app.Config.GeneratedPatterns
+	}
+	return DefaultGeneratedPatterns
+}
+
+// SavedConfigs returns the saved arguments by name for the folder. Names saved
+// for the folder take precedence over globally saved names, and within each,
+// project entries override global config entries.
+func (app *App) SavedConfigs(currentDir string) map[string][]string {
+	saved := make(map[string][]string)
+	for _, folder := range []string{GlobalFolderKey, currentDir} {
+		for _, config := range app.configLayers() {
+			for name, args := range config.Folders[folder].SavedName {
+				saved[name] = args
+			}
+		}
+	}
+	return saved
+}
+
+// configLayers returns the loaded configs from lowest to highest precedence.
+func (app *App) configLayers() []*Config {
+	layers := []*Config{&app.Config}
+	if app.ProjectConfig != nil {
+		layers = append(layers, app.ProjectConfig)
+	}
+	return layers
+}
+
+// ResolveConfigPath returns the config file path from -config, GFE_CONFIG or
+// the default ~/.config/go-file-extract/config.json, in that order.
+func ResolveConfigPath(args []string) (string, error) {
+	if path, found := findFlagValue(args, "config"); found {
+		return path, nil
+	}
+	if path := os.Getenv(ConfigEnvVar); path != "" {
+		return path, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	configPath := filepath.Join(homeDir, ".config", AppName, "config.json")
+
+	// Keep using a config written under the old placeholder directory name
+	legacyPath := filepath.Join(homeDir, ".config", "your_app_name", "config.json")
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		if _, err := os.Stat(legacyPath); err == nil {
+			return legacyPath, nil
+		}
+	}
+	return configPath, nil
+}
+
+// SavedConfig retrieves the saved configuration for the given folder and name.
+func (app *App) SavedConfig(currentDir, name string) ([]string, error) {
+	savedArgs := app.SavedConfigs(currentDir)[name]
+	if len(savedArgs) == 0 {
+		return nil, fmt.Errorf("no saved arguments found for name '%s' in folder '%s'", name, currentDir)
+	}
+	return savedArgs, nil
+}
+
+// MatchSavedName resolves query to one of names. An exact name wins; otherwise
+// the query must match exactly one name, first as a prefix and then anywhere
+// in the name, ignoring case. The error lists the candidates when several match.
+func MatchSavedName(names []string, query string) (string, error) {
+	if slices.Contains(names, query) {
+		return query, nil
+	}
+	lowerQuery := strings.ToLower(query)
+	for _, matches := range []func(name string) bool{
+		func(name string) bool { return strings.HasPrefix(strings.ToLower(name), lowerQuery) },
+		func(name string) bool { return strings.Contains(strings.ToLower(name), lowerQuery) },
+	} {
+		var candidates []string
+		for _, name := range names {
+			if matches(name) {
+				candidates = append(candidates, name)
+			}
+		}
+		slices.Sort(candidates)
+		switch len(candidates) {
+		case 0:
+			continue
+		case 1:
+			return candidates[0], nil
+		default:
+			return "", fmt.Errorf("'%s' matches several saved configurations: %s", query, strings.Join(candidates, ", "))
+		}
+	}
+	return "", fmt.Errorf("no saved configuration matches '%s'", query)
+}
+
+// SaveCurrentConfig saves the current arguments under the specified name for the given folder.
+func (app *App) SaveCurrentConfig(currentDir, name string, args []string) error {
+	if app.Config.Folders == nil {
+		app.Config.Folders = make(map[string]FolderConfig)
+	}
+	folderConfig := app.Config.Folders[currentDir]
+	if folderConfig.SavedName == nil {
+		folderConfig.SavedName = make(map[string][]string)
+	}
+	// Filter out the saving flags, -by-name, -config and their values so the saved arguments replay cleanly
+	filteredArgs := FilterOutFlags(args, "-name", "-save-global", "-by-name", "-config", "-no-backup")
+	folderConfig.SavedName[name] = filteredArgs
+	app.Config.Folders[currentDir] = folderConfig
+	return app.saveConfig()
+}
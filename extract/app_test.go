@@ -0,0 +1,114 @@
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, path string)
+		wantErr bool
+	}{
+		{
+			name:  "new file",
+			setup: func(t *testing.T, path string) {},
+		},
+		{
+			name: "replaces existing file",
+			setup: func(t *testing.T, path string) {
+				if err := os.WriteFile(path, []byte("old content that is longer"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "rename fails",
+			setup: func(t *testing.T, path string) {
+				// A non-empty directory cannot be replaced by a file
+				if err := os.MkdirAll(filepath.Join(path, "keep"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "config.json")
+			tt.setup(t, path)
+
+			err := writeFileAtomic(path, []byte("new"), 0644)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeFileAtomic error = %v, want error %t", err, tt.wantErr)
+			}
+
+			// The temporary file is gone whether or not the rename happened
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || entries[0].Name() != "config.json" {
+				var names []string
+				for _, entry := range entries {
+					names = append(names, entry.Name())
+				}
+				t.Errorf("directory holds %q, want only config.json", names)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != "new" {
+				t.Errorf("content = %q, want %q", data, "new")
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if runtime.GOOS != "windows" && info.Mode().Perm() != 0644 {
+				t.Errorf("permissions = %v, want %v", info.Mode().Perm(), os.FileMode(0644))
+			}
+		})
+	}
+}
+
+func TestMatchSavedName(t *testing.T) {
+	names := []string{"go-files", "go", "test-files", "Docs", "go-tests"}
+	tests := []struct {
+		query   string
+		want    string
+		wantErr string
+	}{
+		{query: "go", want: "go"},
+		{query: "test", want: "test-files"},
+		{query: "doc", want: "Docs"},
+		{query: "GO-F", want: "go-files"},
+		{query: "tests", want: "go-tests"},
+		{query: "go-", wantErr: "'go-' matches several saved configurations: go-files, go-tests"},
+		{query: "files", wantErr: "'files' matches several saved configurations: go-files, test-files"},
+		{query: "rust", wantErr: "no saved configuration matches 'rust'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := MatchSavedName(names, tt.query)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("MatchSavedName(%q) error = %v, want %q", tt.query, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("MatchSavedName(%q) = %q, %v, want %q", tt.query, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package extract
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// ArchiveSeparator separates an archive from the entries selected inside it,
+// as in -files src.zip!cmd/main.go.
+const ArchiveSeparator = "!"
+
+// MaxArchiveEntrySize caps the bytes read from a single archive entry, so a
+// large or malicious entry cannot exhaust memory; larger entries fail the archive.
+const MaxArchiveEntrySize = 64 << 20
+
+// isArchive reports whether the path points to an archive supported by -files.
+func isArchive(path string) bool {
+	lower := strings.ToLower(path)
+	for _, suffix := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitArchivePath splits a path such as src.zip!cmd/main.go into the archive
+// and the entry selector. ok is false when the path does not point into an archive.
+func splitArchivePath(filePath string) (archive, selector string, ok bool) {
+	archive, selector, found := strings.Cut(filePath, ArchiveSeparator)
+	if !found || !isArchive(archive) {
+		return filePath, "", false
+	}
+	return archive, strings.Trim(selector, "/"), true
+}
+
+// readArchive returns the regular files stored in a .zip, .tar or .tar.gz archive.
+func readArchive(path string) ([]sourceFile, error) {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return readZip(path)
+	case strings.HasSuffix(lower, ".tar"):
+		return readTar(path)
+	default:
+		return readTarGz(path)
+	}
+}
+
+// selectEntries returns the entries selected by an archive path selector: the
+// entry itself, every entry under it when it names a directory, or the entries
+// matching it as a path.Match pattern.
+func selectEntries(entries []sourceFile, selector string) []sourceFile {
+	var selected []sourceFile
+	for _, entry := range entries {
+		name := strings.TrimPrefix(entry.Path, "./")
+		matched, _ := path.Match(selector, name)
+		if matched || name == selector || strings.HasPrefix(name, selector+"/") {
+			selected = append(selected, entry)
+		}
+	}
+	return selected
+}
+
+// readZip reads every regular file entry from a zip archive.
+func readZip(path string) ([]sourceFile, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip archive: %v", err)
+	}
+	defer reader.Close()
+
+	var entries []sourceFile
+	for _, file := range reader.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open zip entry %s: %v", file.Name, err)
+		}
+		content, err := readEntry(rc, MaxArchiveEntrySize)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read zip entry %s: %v", file.Name, err)
+		}
+		entries = append(entries, sourceFile{Path: file.Name, Content: content, ModTime: file.Modified, InMemory: true})
+	}
+	return entries, nil
+}
+
+// readTar reads every regular file entry from an uncompressed tarball.
+func readTar(path string) ([]sourceFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tar archive: %v", err)
+	}
+	defer f.Close()
+	return readTarEntries(f)
+}
+
+// readTarGz reads every regular file entry from a gzip-compressed tarball.
+func readTarGz(path string) ([]sourceFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tar archive: %v", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress tar archive: %v", err)
+	}
+	defer gz.Close()
+	return readTarEntries(gz)
+}
+
+// readTarEntries reads every regular file entry from a tar stream.
+func readTarEntries(r io.Reader) ([]sourceFile, error) {
+	var entries []sourceFile
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar archive: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := readEntry(tr, MaxArchiveEntrySize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar entry %s: %v", header.Name, err)
+		}
+		entries = append(entries, sourceFile{Path: header.Name, Content: content, ModTime: header.ModTime, InMemory: true})
+	}
+	return entries, nil
+}
+
+// readEntry reads an archive entry of at most limit bytes. The size recorded
+// in the archive is not trusted; the bytes are counted as they are read.
+func readEntry(r io.Reader, limit int) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > limit {
+		return nil, fmt.Errorf("entry is larger than %d bytes", limit)
+	}
+	return content, nil
+}
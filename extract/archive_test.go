@@ -0,0 +1,239 @@
+package extract
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// archiveFiles are the entries written by writeArchive, in order.
+var archiveFiles = []struct{ Name, Content string }{
+	{"README.md", "# Bundle\n"},
+	{"src/main.go", "package main\n"},
+	{"src/util/util.go", "package util\n"},
+	{"docs/guide.md", "# Guide\n"},
+}
+
+// writeArchive writes archiveFiles, with a directory entry, to an archive in
+// dir named name, in the format its extension selects, and returns its path.
+func writeArchive(t *testing.T, dir, name string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	lower := strings.ToLower(name)
+	if strings.HasSuffix(lower, ".zip") {
+		zw := zip.NewWriter(&buf)
+		if _, err := zw.Create("src/"); err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range archiveFiles {
+			w, err := zw.Create(file.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(file.Content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		var tarBuf bytes.Buffer
+		tw := tar.NewWriter(&tarBuf)
+		if err := tw.WriteHeader(&tar.Header{Name: "src/", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range archiveFiles {
+			if err := tw.WriteHeader(&tar.Header{Name: file.Name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(file.Content))}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write([]byte(file.Content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasSuffix(lower, ".tar") {
+			buf = tarBuf
+		} else {
+			gz := gzip.NewWriter(&buf)
+			if _, err := gz.Write(tarBuf.Bytes()); err != nil {
+				t.Fatal(err)
+			}
+			if err := gz.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// entryNames returns the paths of entries.
+func entryNames(entries []sourceFile) []string {
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Path
+	}
+	return names
+}
+
+func TestReadArchive(t *testing.T) {
+	var want []string
+	for _, file := range archiveFiles {
+		want = append(want, file.Name)
+	}
+	for _, name := range []string{"bundle.zip", "bundle.tar", "bundle.tar.gz", "bundle.tgz", "BUNDLE.ZIP"} {
+		t.Run(name, func(t *testing.T) {
+			entries, err := readArchive(writeArchive(t, t.TempDir(), name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := entryNames(entries); !slices.Equal(got, want) {
+				t.Fatalf("readArchive(%s) entries = %q, want %q", name, got, want)
+			}
+			for i, entry := range entries {
+				if !entry.InMemory || string(entry.Content) != archiveFiles[i].Content {
+					t.Errorf("entry %s = %q (in memory %t), want %q in memory", entry.Path, entry.Content, entry.InMemory, archiveFiles[i].Content)
+				}
+			}
+		})
+	}
+}
+
+func TestReadArchiveCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	valid, err := os.ReadFile(writeArchive(t, dir, "valid.tar.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{"bundle.zip", []byte("not a zip archive")},
+		{"bundle.tar", bytes.Repeat([]byte("x"), 1024)},
+		{"bundle.tar.gz", []byte("not gzip data")},
+		{"truncated.tar.gz", valid[:len(valid)/2]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, tt.content, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if entries, err := readArchive(path); err == nil {
+				t.Errorf("readArchive(%s) = %q, want an error", tt.name, entryNames(entries))
+			}
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		if _, err := readArchive(filepath.Join(dir, "missing.zip")); err == nil {
+			t.Error("readArchive(missing.zip) error = nil, want an error")
+		}
+	})
+}
+
+func TestReadEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		limit   int
+		wantErr bool
+	}{
+		{"empty", "", 4, false},
+		{"under the limit", "abc", 4, false},
+		{"at the limit", "abcd", 4, false},
+		{"over the limit", "abcde", 4, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readEntry(strings.NewReader(tt.content), tt.limit)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "larger than 4 bytes") {
+					t.Errorf("readEntry(%q, %d) error = %v, want a size error", tt.content, tt.limit, err)
+				}
+				return
+			}
+			if err != nil || string(got) != tt.content {
+				t.Errorf("readEntry(%q, %d) = %q, %v, want %q", tt.content, tt.limit, got, err, tt.content)
+			}
+		})
+	}
+}
+
+func TestSelectEntries(t *testing.T) {
+	var entries []sourceFile
+	for _, file := range archiveFiles {
+		entries = append(entries, sourceFile{Path: file.Name})
+	}
+	entries = append(entries, sourceFile{Path: "./dot/slash.go"})
+
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{"README.md", []string{"README.md"}},
+		{"src", []string{"src/main.go", "src/util/util.go"}},
+		{"src/util", []string{"src/util/util.go"}},
+		{"*.md", []string{"README.md"}},
+		{"*/*.md", []string{"docs/guide.md"}},
+		{"dot/slash.go", []string{"./dot/slash.go"}},
+		{"sr", nil},
+		{"missing.go", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			if got := entryNames(selectEntries(entries, tt.selector)); !slices.Equal(got, tt.want) {
+				t.Errorf("selectEntries(%q) = %q, want %q", tt.selector, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := writeArchive(t, dir, "bundle.zip")
+	tgzPath := writeArchive(t, dir, "bundle.tar.gz")
+	names := []string{"README.md", "src/main.go", "src/util/util.go", "docs/guide.md"}
+
+	tests := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{"whole zip", []string{zipPath}, names},
+		{"whole tar.gz", []string{tgzPath}, names},
+		{"directory in a zip", []string{zipPath + "!src"}, []string{"src/main.go", "src/util/util.go"}},
+		{"glob in a tar.gz", []string{tgzPath + "!docs/*.md"}, []string{"docs/guide.md"}},
+		{"trailing slash", []string{zipPath + "!/src/util/"}, []string{"src/util/util.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractIn(t, dir, append([]string{"-files"}, tt.files...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if headers := extractedHeaders(got, names); !slices.Equal(headers, tt.want) {
+				t.Errorf("extracted %q, want %q", headers, tt.want)
+			}
+		})
+	}
+
+	t.Run("no matching entry", func(t *testing.T) {
+		if _, err := extractIn(t, dir, "-files", zipPath+"!missing.go", "-strict"); err == nil || !strings.Contains(err.Error(), "no entry matches") {
+			t.Errorf("extracting a missing entry error = %v, want it to report no matching entry", err)
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CacheMaxBytes caps the size of the cache directory. After each extraction
+// the least recently used entries are removed until it fits.
+const CacheMaxBytes = 64 << 20
+
+// CacheDir returns the directory holding processed files between runs,
+// ~/.cache/go-file-extract on Linux.
+func CacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, AppName), nil
+}
+
+// ClearCache removes every cached file.
+func ClearCache() error {
+	dir, err := CacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return nil
+}
+
+// cachedFile is a processed file as stored in the cache. Its header and code
+// fence are not stored but built for each run, since they depend on the path
+// the file was given by.
+type cachedFile struct {
+	Language    string
+	Text        string // The content after trimming, redaction and other processing
+	ExecOutput  string // Labelled executable output, empty in batch mode or without an executable
+	ContentHash string // Set with -dedupe-content
+}
+
+// fileCache stores processed files on disk with -cache so unchanged files are
+// neither read nor run through their executable again. Entries are keyed by the
+// file's path, modification time, size and mode, and by a fingerprint of
+// everything else that shapes the output, so changing a flag or the config
+// never returns a stale entry.
+type fileCache struct {
+	dir         string
+	fingerprint string
+}
+
+// newFileCache returns the cache for an extraction, or nil unless -cache is
+// set without -no-cache and there is a cache directory.
+func newFileCache(opts *Options, fileTypeExecutables map[string]string, redactPatterns, secretFiles, generatedPatterns []string) *fileCache {
+	if !opts.Cache || opts.NoCache {
+		return nil
+	}
+	dir, err := CacheDir()
+	if err != nil {
+		return nil
+	}
+
+	// The files and diff pairs only choose what is extracted, not how, and
+	// the remaining flags cleared here only affect logging, speed or where the
+	// output goes
+	fingerprintOpts := *opts
+	fingerprintOpts.Files = nil
+	fingerprintOpts.Diff = nil
+	fingerprintOpts.Verbose = false
+	fingerprintOpts.Quiet = false
+	fingerprintOpts.ClearCache = false
+	fingerprintOpts.Jobs = 0
+	fingerprintOpts.NoClipboard = false
+	fingerprintOpts.VerifyClipboard = false
+	data, err := json.Marshal(struct {
+		Options             Options
+		FileTypeExecutables map[string]string
+		RedactPatterns      []string
+		SecretFiles         []string
+		GeneratedPatterns   []string
+	}{fingerprintOpts, fileTypeExecutables, redactPatterns, secretFiles, generatedPatterns})
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	return &fileCache{dir: dir, fingerprint: hex.EncodeToString(sum[:])}
+}
+
+// key returns the cache key for the file at path as it is now, or "" if it
+// cannot be stat'd.
+func (c *fileCache) key(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d\x00%d\x00%s", c.fingerprint, absPath, info.ModTime().UnixNano(), info.Size(), info.Mode())))
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns the entry stored under key.
+func (c *fileCache) get(key string) (cachedFile, bool) {
+	var entry cachedFile
+	if key == "" {
+		return entry, false
+	}
+	path := filepath.Join(c.dir, key+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, false
+	}
+	// Mark the entry as recently used for prune
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return entry, true
+}
+
+// put stores entry under key. A failure only costs a cache miss next time.
+func (c *fileCache) put(key string, entry cachedFile) error {
+	if key == "" {
+		return nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	// Entries hold file contents, so only the user may read them
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %v", err)
+	}
+	if err := writeFileAtomic(filepath.Join(c.dir, key+".json"), data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache entry: %v", err)
+	}
+	return nil
+}
+
+// prune removes the least recently used entries until the cache holds at
+// most maxBytes.
+func (c *fileCache) prune(maxBytes int64) error {
+	dirEntries, err := os.ReadDir(c.dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cache directory: %v", err)
+	}
+	var entries []os.FileInfo
+	var total int64
+	for _, dirEntry := range dirEntries {
+		if !strings.HasSuffix(dirEntry.Name(), ".json") {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, info)
+		total += info.Size()
+	}
+	slices.SortFunc(entries, func(a, b os.FileInfo) int {
+		return a.ModTime().Compare(b.ModTime())
+	})
+	for _, info := range entries {
+		if total <= maxBytes {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, info.Name())); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to prune cache: %v", err)
+		}
+		total -= info.Size()
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package extract
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useTempCache points CacheDir at a temporary directory and returns it.
+func useTempCache(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dir, err := CacheDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// cacheEntries returns the paths of the entries in the cache directory.
+func cacheEntries(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestNewFileCacheOptIn(t *testing.T) {
+	useTempCache(t)
+	tests := []struct {
+		cache, noCache bool
+		want           bool
+	}{
+		{false, false, false},
+		{true, false, true},
+		{true, true, false},
+		{false, true, false},
+	}
+	for _, tt := range tests {
+		opts := &Options{Cache: tt.cache, NoCache: tt.noCache}
+		if got := newFileCache(opts, nil, nil, nil, nil) != nil; got != tt.want {
+			t.Errorf("newFileCache with Cache %t, NoCache %t enabled = %t, want %t", tt.cache, tt.noCache, got, tt.want)
+		}
+	}
+}
+
+func TestFileCacheKey(t *testing.T) {
+	useTempCache(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.go")
+	writeFiles(t, dir, map[string]string{"a.go": "package a\n"})
+
+	cache := newFileCache(&Options{Cache: true}, nil, nil, nil, nil)
+	key := cache.key(path)
+	if key == "" {
+		t.Fatal("key is empty for an existing file")
+	}
+	if got := cache.key(filepath.Join(dir, "sub", "..", "a.go")); got != key {
+		t.Errorf("key differs for another spelling of the same path")
+	}
+	if got := cache.key(filepath.Join(dir, "missing.go")); got != "" {
+		t.Errorf("key for a missing file = %q, want empty", got)
+	}
+
+	other := newFileCache(&Options{Cache: true, TrimBlankLines: true}, nil, nil, nil, nil)
+	if other.key(path) == key {
+		t.Error("key is unchanged after an option that shapes the output changed")
+	}
+	quiet := newFileCache(&Options{Cache: true, Quiet: true, Jobs: 4}, nil, nil, nil, nil)
+	if quiet.key(path) != key {
+		t.Error("key changed after options that do not shape the output changed")
+	}
+	executables := newFileCache(&Options{Cache: true}, map[string]string{".go": "gofmt -l"}, nil, nil, nil)
+	if executables.key(path) == key {
+		t.Error("key is unchanged after the configured executables changed")
+	}
+
+	if err := os.WriteFile(path, []byte("package b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if cache.key(path) == key {
+		t.Error("key is unchanged after the file changed")
+	}
+}
+
+func TestExtractCache(t *testing.T) {
+	cacheDir := useTempCache(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.go": "package a\n", ".env": "TOKEN=abc\n"})
+	app, err := NewApp(filepath.Join(t.TempDir(), "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(args ...string) string {
+		t.Helper()
+		opts, err := ParseArguments(args, app.DefaultDelimiter(), app.Defaults())
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := app.Extract(*opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return output
+	}
+
+	// Without -cache nothing is stored
+	path := filepath.Join(dir, "a.go")
+	run("-files", path)
+	if entries := cacheEntries(t, cacheDir); len(entries) != 0 {
+		t.Fatalf("cache holds %d entries without -cache, want none", len(entries))
+	}
+
+	run("-files", path, "-cache")
+	entries := cacheEntries(t, cacheDir)
+	if len(entries) != 1 {
+		t.Fatalf("cache holds %d entries, want 1", len(entries))
+	}
+	if info, err := os.Stat(entries[0]); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("cache entry permissions = %v, want %v", info.Mode().Perm(), os.FileMode(0600))
+	}
+
+	// Mark the entry so a hit is visible in the output
+	data, err := os.ReadFile(entries[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry cachedFile
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	entry.Text = "package cached\n"
+	if data, err = json.Marshal(entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(entries[0], data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The header is built from the path given in this run, not the cached one
+	otherPath := filepath.Join(dir, "sub", "..", "a.go")
+	got := run("-files", otherPath, "-cache")
+	want := otherPath + "\n```go\npackage cached\n\n```\n======\n"
+	if got != want {
+		t.Errorf("output after a cache hit = %q, want %q", got, want)
+	}
+
+	// Secret and force-included files are never stored
+	run("-files", filepath.Join(dir, ".env"), "-include-secrets", "-cache", "-quiet")
+	writeFiles(t, dir, map[string]string{"b.go": "package b\n"})
+	run("-files", filepath.Join(dir, "b.go"), "-force-include", `b\.go$`, "-cache")
+	if got := cacheEntries(t, cacheDir); !slices.Equal(got, entries) {
+		t.Errorf("cache entries = %q, want only %q", got, entries)
+	}
+}
+
+func TestFileCachePrune(t *testing.T) {
+	dir := t.TempDir()
+	cache := &fileCache{dir: dir}
+	start := time.Now().Add(-time.Hour)
+	for i, key := range []string{"oldest", "older", "newer", "newest"} {
+		if err := cache.put(key, cachedFile{Text: strings.Repeat("x", 100)}); err != nil {
+			t.Fatal(err)
+		}
+		used := start.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(filepath.Join(dir, key+".json"), used, used); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Reading an entry marks it as the most recently used
+	if _, ok := cache.get("oldest"); !ok {
+		t.Fatal("get missed an entry that was just stored")
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "newest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.prune(2 * info.Size()); err != nil {
+		t.Fatal(err)
+	}
+	var kept []string
+	for _, entry := range cacheEntries(t, dir) {
+		kept = append(kept, strings.TrimSuffix(filepath.Base(entry), ".json"))
+	}
+	if want := []string{"newest", "oldest"}; !slices.Equal(kept, want) {
+		t.Errorf("entries kept after prune = %q, want %q", kept, want)
+	}
+}
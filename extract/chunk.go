@@ -0,0 +1,83 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// chunkHeaderReserve is the room left in each chunk for its "Part X of Y" line.
+const chunkHeaderReserve = 32
+
+// SplitChunks splits output into chunks of at most maxBytes, each starting
+// with a "Part X of Y" line. Chunks break after a delimiter line so files stay
+// whole; a file larger than a chunk is split between lines, and a line larger
+// than a chunk between characters.
+func SplitChunks(output, delimiter string, maxBytes int) []string {
+	limit := max(maxBytes-chunkHeaderReserve, 1)
+
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+	}
+	add := func(piece string) {
+		if current.Len()+len(piece) > limit {
+			flush()
+		}
+		current.WriteString(piece)
+	}
+
+	for _, section := range splitAfterDelimiter(output, delimiter) {
+		if len(section) <= limit {
+			add(section)
+			continue
+		}
+		for _, line := range strings.SplitAfter(section, "\n") {
+			for len(line) > limit {
+				cut := limit
+				for cut > 0 && !utf8.RuneStart(line[cut]) {
+					cut--
+				}
+				if cut == 0 {
+					cut = limit
+				}
+				add(line[:cut])
+				line = line[cut:]
+			}
+			add(line)
+		}
+	}
+	flush()
+
+	for i, chunk := range chunks {
+		chunks[i] = fmt.Sprintf("Part %d of %d\n%s", i+1, len(chunks), chunk)
+	}
+	return chunks
+}
+
+// splitAfterDelimiter splits output into sections that each end with a
+// delimiter line, in any -delimiter-style, except possibly the last.
+func splitAfterDelimiter(output, delimiter string) []string {
+	var sections []string
+	start := 0
+	for offset := 0; offset < len(output); {
+		end := strings.IndexByte(output[offset:], '\n')
+		if end < 0 {
+			break
+		}
+		end += offset + 1
+		if isDelimiterLine(output[offset:end-1], delimiter) {
+			sections = append(sections, output[start:end])
+			start = end
+		}
+		offset = end
+	}
+	if start < len(output) {
+		sections = append(sections, output[start:])
+	}
+	return sections
+}
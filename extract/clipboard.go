@@ -0,0 +1,108 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+
+	"github.com/atotto/clipboard"
+)
+
+// Values accepted by -clipboard-selection.
+const (
+	SelectionClipboard = "clipboard" // The regular clipboard, pasted with Ctrl-V
+	SelectionPrimary   = "primary"   // The X11/Wayland primary selection, pasted with middle click
+)
+
+// Clipboard receives the extracted output.
+type Clipboard interface {
+	Write(text string) error
+}
+
+// NewClipboard returns the clipboard chosen by -clipboard-cmd and -clipboard-selection.
+func NewClipboard(opts *Options) Clipboard {
+	if opts.ClipboardCmd != "" {
+		return commandClipboard{command: opts.ClipboardCmd}
+	}
+	return systemClipboard{primary: opts.ClipboardSelection == SelectionPrimary, verify: opts.VerifyClipboard && !opts.Quiet}
+}
+
+// systemClipboard writes through the platform clipboard tools found by atotto/clipboard.
+type systemClipboard struct {
+	primary bool // Write to the primary selection instead of the clipboard
+	verify  bool // Read the text back after writing it and warn if it differs, for -verify-clipboard
+}
+
+func (c systemClipboard) Write(text string) error {
+	if c.primary {
+		if err := usePrimarySelection(); err != nil {
+			return err
+		}
+	}
+	if err := clipboard.WriteAll(text); err != nil {
+		return err
+	}
+	if c.verify {
+		verifyClipboard(text)
+	}
+	return nil
+}
+
+// verifyClipboard reads the clipboard back and warns if it does not hold
+// text, as happens when a clipboard tool exits successfully without taking
+// ownership of the selection. The write itself is not treated as failed.
+func verifyClipboard(text string) {
+	got, err := clipboard.ReadAll()
+	if err != nil {
+		log.Printf("Warning: could not read the clipboard back to verify it: %v", err)
+		return
+	}
+	if got != text {
+		log.Printf("Warning: the clipboard does not hold the output: wrote %d bytes, read back %d bytes", len(text), len(got))
+	}
+}
+
+// commandClipboard pipes the output into a user-supplied copy command.
+type commandClipboard struct {
+	command string
+}
+
+func (c commandClipboard) Write(text string) error {
+	return runClipboardCommand(c.command, text)
+}
+
+// MemoryClipboard keeps the last text written to it, for callers that want
+// the output without touching the system clipboard.
+type MemoryClipboard struct {
+	Text string
+}
+
+func (c *MemoryClipboard) Write(text string) error {
+	c.Text = text
+	return nil
+}
+
+// runClipboardCommand runs a copy command such as "wl-copy" or
+// "xclip -selection clipboard" with text on its stdin. Such tools often fork
+// a process that keeps owning the clipboard, so stdout is not captured and
+// the wait for stderr to close is bounded.
+func runClipboardCommand(command, text string) error {
+	args, err := tokenizeCommand(command)
+	if err != nil {
+		return fmt.Errorf("invalid -clipboard-cmd: %v", err)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("invalid -clipboard-cmd: empty command")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	stderr := &cappedBuffer{limit: DefaultExecMaxOutput}
+	cmd.Stderr = stderr
+	cmd.WaitDelay = execWaitDelay
+	if err := cmd.Run(); err != nil && !errors.Is(err, exec.ErrWaitDelay) {
+		return fmt.Errorf("%s failed: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !(freebsd || linux || netbsd || openbsd || solaris || dragonfly)
+
+package extract
+
+import "errors"
+
+// usePrimarySelection reports that this platform has no primary selection.
+func usePrimarySelection() error {
+	return errors.New("the primary selection is only available on Linux and BSD")
+}
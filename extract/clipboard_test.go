@@ -0,0 +1,45 @@
+package extract
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunClipboardCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep is not available")
+	}
+	tests := []struct {
+		name    string
+		command string
+		wantErr string // Empty when the command should succeed
+	}{
+		{"reads stdin", `sh -c "cat >/dev/null"`, ""},
+		{"fails with stderr", `sh -c "cat >/dev/null; echo no display >&2; exit 1"`, "no display"},
+		{"forks a clipboard owner", `sh -c "cat >/dev/null; (sleep 3) &"`, ""},
+		{"empty command", ` `, "empty command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			err := runClipboardCommand(tt.command, "text")
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("runClipboardCommand(%q) returned after %s, want it not to wait for forked processes", tt.command, elapsed)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("runClipboardCommand(%q) error = %v, want nil", tt.command, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("runClipboardCommand(%q) error = %v, want it to mention %q", tt.command, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+//go:build freebsd || linux || netbsd || openbsd || solaris || dragonfly
+
+package extract
+
+import "github.com/atotto/clipboard"
+
+// usePrimarySelection makes clipboard writes target the primary selection.
+func usePrimarySelection() error {
+	clipboard.Primary = true
+	return nil
+}
@@ -0,0 +1,133 @@
+package extract
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Shells supported by -completion.
+const (
+	ShellBash = "bash"
+	ShellZsh  = "zsh"
+	ShellFish = "fish"
+)
+
+var completionShells = []string{ShellBash, ShellZsh, ShellFish}
+
+// completionCommand is the command name the completion scripts register for.
+const completionCommand = "go-file-extract"
+
+// completionValues lists the fixed values offered after flags that take one.
+var completionValues = map[string][]string{
+	"completion":           completionShells,
+	"sort":                 sortKeys,
+	"delimiter-style":      delimiterStyles,
+	"format":               formats,
+	"exec-mode":            execModes,
+	"exec-output-position": execOutputPositions,
+	"fence-style":          {FenceStyleBacktick, FenceStyleTilde},
+	"clipboard-selection":  {SelectionClipboard, SelectionPrimary},
+}
+
+// WriteCompletion writes a completion script for shell to w. Flag names and
+// their fixed values are completed statically; saved names after -by-name are
+// looked up at completion time with -list-saved.
+func WriteCompletion(w io.Writer, shell string) error {
+	var flags []*flag.Flag
+	flagDefinitions().VisitAll(func(f *flag.Flag) { flags = append(flags, f) })
+
+	switch shell {
+	case ShellBash:
+		writeBashCompletion(w, flags)
+	case ShellZsh:
+		writeZshCompletion(w, flags)
+	case ShellFish:
+		writeFishCompletion(w, flags)
+	default:
+		return fmt.Errorf("unsupported shell %q. Expected one of %s", shell, strings.Join(completionShells, ", "))
+	}
+	return nil
+}
+
+func writeBashCompletion(w io.Writer, flags []*flag.Flag) {
+	names := make([]string, len(flags))
+	for i, f := range flags {
+		names[i] = "-" + f.Name
+	}
+
+	fmt.Fprintf(w, "# bash completion for %s\n", completionCommand)
+	fmt.Fprintln(w, "_go_file_extract() {")
+	fmt.Fprintln(w, `	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w, `	case "$prev" in`)
+	fmt.Fprintf(w, "\t-by-name)\n\t\tCOMPREPLY=($(compgen -W \"$(%s -list-saved 2>/dev/null)\" -- \"$cur\"))\n\t\treturn ;;\n", completionCommand)
+	for _, f := range flags {
+		if values, ok := completionValues[f.Name]; ok {
+			fmt.Fprintf(w, "\t-%s)\n\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n\t\treturn ;;\n", f.Name, strings.Join(values, " "))
+		}
+	}
+	fmt.Fprintln(w, "\tesac")
+	fmt.Fprintln(w, `	if [[ "$cur" == -* ]]; then`)
+	fmt.Fprintf(w, "\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintln(w, "\t\treturn")
+	fmt.Fprintln(w, "\tfi")
+	fmt.Fprintln(w, `	COMPREPLY=($(compgen -f -- "$cur"))`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintf(w, "complete -o filenames -F _go_file_extract %s\n", completionCommand)
+}
+
+func writeZshCompletion(w io.Writer, flags []*flag.Flag) {
+	fmt.Fprintf(w, "#compdef %s\n\n", completionCommand)
+	fmt.Fprintln(w, "_go_file_extract_saved() {")
+	fmt.Fprintf(w, "\tlocal -a names\n\tnames=(${(f)\"$(%s -list-saved 2>/dev/null)\"})\n", completionCommand)
+	fmt.Fprintln(w, "\tcompadd -a names")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "_arguments \\")
+	for _, f := range flags {
+		spec := fmt.Sprintf("-%s[%s]", f.Name, zshEscape(firstLine(f.Usage)))
+		switch values, ok := completionValues[f.Name]; {
+		case f.Name == "by-name":
+			spec += ":name:_go_file_extract_saved"
+		case ok:
+			spec += fmt.Sprintf(":%s:(%s)", f.Name, strings.Join(values, " "))
+		case !isBoolFlag(f):
+			spec += ":value:_files"
+		}
+		fmt.Fprintf(w, "\t%s \\\n", shellQuote(spec))
+	}
+	fmt.Fprintln(w, "\t'*:file:_files'")
+}
+
+func writeFishCompletion(w io.Writer, flags []*flag.Flag) {
+	fmt.Fprintf(w, "# fish completion for %s\n", completionCommand)
+	for _, f := range flags {
+		line := fmt.Sprintf("complete -c %s -o %s -d %s", completionCommand, f.Name, shellQuote(firstLine(f.Usage)))
+		switch values, ok := completionValues[f.Name]; {
+		case f.Name == "by-name":
+			line += fmt.Sprintf(" -x -a '(%s -list-saved 2>/dev/null)'", completionCommand)
+		case ok:
+			line += " -x -a " + shellQuote(strings.Join(values, " "))
+		case !isBoolFlag(f):
+			line += " -r"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// firstLine returns the first line of a flag's usage text.
+func firstLine(usage string) string {
+	line, _, _ := strings.Cut(usage, "\n")
+	return line
+}
+
+// zshEscape escapes the characters _arguments treats specially in descriptions.
+func zshEscape(s string) string {
+	return strings.NewReplacer(`[`, `\[`, `]`, `\]`, `:`, `\:`).Replace(s)
+}
+
+// shellQuote wraps s in single quotes for bash, zsh and fish.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,50 @@
+package extract
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CompressedPrefix starts every -compress output so the encoding can be
+// recognised. The rest is the base64 (standard alphabet) of the gzipped text.
+const CompressedPrefix = "gfe-gzip-base64:"
+
+// CompressOutput gzips text and encodes it as base64 behind CompressedPrefix.
+func CompressOutput(text string) (string, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(text)); err != nil {
+		return "", fmt.Errorf("failed to compress output: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress output: %v", err)
+	}
+	return CompressedPrefix + base64.StdEncoding.EncodeToString(buf.Bytes()) + "\n", nil
+}
+
+// DecompressOutput reverses CompressOutput. Surrounding whitespace is ignored.
+func DecompressOutput(encoded string) (string, error) {
+	payload, found := strings.CutPrefix(strings.TrimSpace(encoded), CompressedPrefix)
+	if !found {
+		return "", errors.New("input does not start with " + CompressedPrefix)
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode base64: %v", err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress: %v", err)
+	}
+	defer gz.Close()
+	text, err := io.ReadAll(gz)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress: %v", err)
+	}
+	return string(text), nil
+}
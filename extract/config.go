@@ -0,0 +1,165 @@
+package extract
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProjectConfigName is the project-local config file looked up from the working directory.
+const ProjectConfigName = ".gofileextract.json"
+
+// findProjectConfig returns the nearest project config file in dir or its parents.
+func findProjectConfig(dir string) (string, bool) {
+	return findProjectFile(dir, ProjectConfigName)
+}
+
+// findProjectFile returns the nearest file with the given name in dir or its
+// parents. The search stops at the git repository root, or at the filesystem
+// root outside a repository.
+func findProjectFile(dir, name string) (string, bool) {
+	for {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", false // Reached the git root
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// LoadProjectConfig loads the nearest project config above dir, if there is one.
+// Relative folder keys in a project config are resolved against the directory
+// holding the file, so a repository can ship presets with "folders": {".": ...}.
+func (app *App) LoadProjectConfig(dir string) error {
+	path, found := findProjectConfig(dir)
+	if !found {
+		return nil
+	}
+	var project Config
+	if err := readConfigFile(path, &project); err != nil {
+		return err
+	}
+	if err := project.validateProject(); err != nil {
+		return fmt.Errorf("invalid project config %s: %v", path, err)
+	}
+
+	projectDir := filepath.Dir(path)
+	folders := make(map[string]FolderConfig, len(project.Folders))
+	for folder, folderConfig := range project.Folders {
+		if !filepath.IsAbs(folder) {
+			folder = filepath.Join(projectDir, folder)
+		}
+		folders[folder] = folderConfig
+	}
+	project.Folders = folders
+
+	app.ProjectConfig = &project
+	app.ProjectConfigPath = path
+	return nil
+}
+
+// validateProject rejects the settings a project config may not set. A
+// repository is not trusted to run commands during extraction or to change
+// which files count as secrets, so only folders, default_delimiter and
+// defaults are honoured; the rest belong in the global config.
+func (config *Config) validateProject() error {
+	var restricted []string
+	if len(config.FileTypeExecutables) > 0 {
+		restricted = append(restricted, "file_type_executables")
+	}
+	if config.RedactPatterns != nil {
+		restricted = append(restricted, "redact_patterns")
+	}
+	if config.SecretFiles != nil {
+		restricted = append(restricted, "secret_files")
+	}
+	if config.GeneratedPatterns != nil {
+		restricted = append(restricted, "generated_patterns")
+	}
+	if config.Backup != nil {
+		restricted = append(restricted, "backup")
+	}
+	if len(restricted) > 0 {
+		return fmt.Errorf("%s can only be set in the global config", strings.Join(restricted, ", "))
+	}
+	return nil
+}
+
+// EffectiveConfig is the fully resolved configuration printed by -print-config.
+type EffectiveConfig struct {
+	ConfigPath          string            `json:"config_path"`
+	ProjectConfigPath   string            `json:"project_config_path,omitempty"`
+	DefaultDelimiter    string            `json:"default_delimiter"`
+	Defaults            Defaults          `json:"defaults"`
+	FileTypeExecutables map[string]string `json:"file_type_executables"` // Config layers merged with -file-exec
+	RedactPatterns      []string          `json:"redact_patterns"`
+	SecretFiles         []string          `json:"secret_files"`
+	GeneratedPatterns   []string          `json:"generated_patterns"`
+	Options             Options           `json:"options"`
+}
+
+// EffectiveConfig merges the global config, the project config and opts the
+// same way Extract does and returns the result.
+func (app *App) EffectiveConfig(opts Options) EffectiveConfig {
+	executables := app.fileTypeExecutables()
+	for ext, cmd := range opts.FileExecs {
+		executables[ext] = cmd
+	}
+	return EffectiveConfig{
+		ConfigPath:          app.ConfigPath,
+		ProjectConfigPath:   app.ProjectConfigPath,
+		DefaultDelimiter:    app.DefaultDelimiter(),
+		Defaults:            app.Defaults(),
+		FileTypeExecutables: executables,
+		RedactPatterns:      app.redactPatterns(),
+		SecretFiles:         app.secretFiles(),
+		GeneratedPatterns:   app.generatedPatterns(),
+		Options:             opts,
+	}
+}
+
+// exampleConfig returns the config written by -init, with every setting
+// present so the file documents its own structure.
+func exampleConfig() Config {
+	wrapCode, ignoreGitIgnore, format, backup := true, false, FormatMarkdown, true
+	return Config{
+		Folders:             map[string]FolderConfig{},
+		FileTypeExecutables: map[string]string{},
+		DefaultDelimiter:    DefaultDelimiter,
+		Defaults:            &Defaults{WrapCode: &wrapCode, IgnoreGitIgnore: &ignoreGitIgnore, Format: &format},
+		RedactPatterns:      []string{},
+		SecretFiles:         DefaultSecretFiles,
+		GeneratedPatterns:   DefaultGeneratedPatterns,
+		Backup:              &backup,
+	}
+}
+
+// InitConfig writes an example config to path. An existing file is only
+// replaced when force is set. The written file is read back to make sure it
+// loads.
+func InitConfig(path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("config file %s already exists; pass -force to overwrite it", path)
+	}
+	data, err := json.MarshalIndent(exampleConfig(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+	if err := writeFileAtomic(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %v", err)
+	}
+	var written Config
+	return readConfigFile(path, &written)
+}
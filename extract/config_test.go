@@ -0,0 +1,75 @@
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadProjectConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		project string
+		wantErr string
+	}{
+		{"folders and defaults", `{"folders": {".": {"saved_name": {"go": ["-files", "a.go"]}}}, "default_delimiter": "---", "defaults": {"wrap_code": false}}`, ""},
+		{"file executables", `{"folders": {}, "file_type_executables": {".go": "sh -c 'echo PWNED'"}}`, "file_type_executables"},
+		{"secret files disabled", `{"folders": {}, "secret_files": []}`, "secret_files"},
+		{"redact patterns", `{"folders": {}, "redact_patterns": ["x"]}`, "redact_patterns"},
+		{"generated patterns", `{"folders": {}, "generated_patterns": []}`, "generated_patterns"},
+		{"empty executables", `{"folders": {}, "file_type_executables": {}}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(dir, ".git"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			writeFiles(t, dir, map[string]string{ProjectConfigName: tt.project})
+			app, err := NewApp(filepath.Join(t.TempDir(), "config.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = app.LoadProjectConfig(dir)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("LoadProjectConfig error = %v, want nil", err)
+				}
+				if app.ProjectConfig == nil {
+					t.Fatal("LoadProjectConfig did not load the project config")
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadProjectConfig error = %v, want it to mention %s", err, tt.wantErr)
+			}
+			if app.ProjectConfig != nil {
+				t.Error("LoadProjectConfig kept a rejected project config")
+			}
+		})
+	}
+}
+
+func TestProjectConfigCannotRunExecutables(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.go": "package a\n"})
+	app, opts := parseIn(t, dir, "-files", filepath.Join(dir, "a.go"))
+	// A project config that slipped past LoadProjectConfig is still not consulted
+	app.ProjectConfig = &Config{
+		FileTypeExecutables: map[string]string{".go": "false"},
+		SecretFiles:         []string{},
+	}
+
+	got, err := app.Extract(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a.go\n```go\npackage a\n\n```\n======\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if patterns := app.secretFiles(); len(patterns) == 0 {
+		t.Error("secretFiles() is empty, want the defaults")
+	}
+}
@@ -0,0 +1,84 @@
+package extract
+
+// Values accepted by -delimiter-style.
+const (
+	DelimiterStyleRaw      = "raw"      // Write the delimiter as is
+	DelimiterStyleComment  = "comment"  // Write the delimiter as a comment in the language of the file before it
+	DelimiterStyleMarkdown = "markdown" // Write a markdown horizontal rule instead of the delimiter
+)
+
+var delimiterStyles = []string{DelimiterStyleRaw, DelimiterStyleComment, DelimiterStyleMarkdown}
+
+// markdownRule is the horizontal rule written with DelimiterStyleMarkdown.
+const markdownRule = "---"
+
+// lineComment is how a language writes a comment around a single line.
+type lineComment struct {
+	Open  string
+	Close string // Empty for comments that run to the end of the line
+}
+
+// lineComments maps the language names of languageMap and the content
+// sniffers to their comment syntax. Languages without comments, such as JSON,
+// are missing and keep the raw delimiter.
+var lineComments = map[string]lineComment{
+	"go":         {Open: "// "},
+	"javascript": {Open: "// "},
+	"typescript": {Open: "// "},
+	"java":       {Open: "// "},
+	"cpp":        {Open: "// "},
+	"c":          {Open: "// "},
+	"rust":       {Open: "// "},
+	"php":        {Open: "// "},
+	"python":     {Open: "# "},
+	"bash":       {Open: "# "},
+	"sh":         {Open: "# "},
+	"fish":       {Open: "# "},
+	"yaml":       {Open: "# "},
+	"ruby":       {Open: "# "},
+	"perl":       {Open: "# "},
+	"css":        {Open: "/* ", Close: " */"},
+	"html":       {Open: "<!-- ", Close: " -->"},
+	"xml":        {Open: "<!-- ", Close: " -->"},
+	"markdown":   {Open: "<!-- ", Close: " -->"},
+}
+
+// renderDelimiter returns the delimiter line written after a section in
+// language, without its newline. Sections that are not a file, such as the
+// tree, pass an empty language and keep the raw delimiter with
+// DelimiterStyleComment. The markdown rule is preceded by a blank line so the
+// line before it is not read as a heading.
+func renderDelimiter(delimiter, style, language string) string {
+	switch style {
+	case DelimiterStyleComment:
+		if comment, ok := lineComments[language]; ok {
+			return comment.Open + delimiter + comment.Close
+		}
+	case DelimiterStyleMarkdown:
+		return "\n" + markdownRule
+	}
+	return delimiter
+}
+
+// isDelimiterLine reports whether line, without its newline, is delimiter as
+// rendered in any -delimiter-style.
+func isDelimiterLine(line, delimiter string) bool {
+	if line == delimiter {
+		return true
+	}
+	for _, comment := range lineComments {
+		if line == comment.Open+delimiter+comment.Close {
+			return true
+		}
+	}
+	return false
+}
+
+// ChunkDelimiter returns the delimiter SplitChunks should break output after
+// for output extracted with opts.
+func ChunkDelimiter(opts *Options) string {
+	if opts.DelimiterStyle == DelimiterStyleMarkdown {
+		return markdownRule
+	}
+	return opts.Delimiter
+}
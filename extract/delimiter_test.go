@@ -0,0 +1,50 @@
+package extract
+
+import "testing"
+
+func TestRenderDelimiter(t *testing.T) {
+	tests := []struct {
+		style    string
+		language string
+		want     string
+	}{
+		{DelimiterStyleRaw, "go", "======"},
+		{DelimiterStyleComment, "go", "// ======"},
+		{DelimiterStyleComment, "python", "# ======"},
+		{DelimiterStyleComment, "css", "/* ====== */"},
+		{DelimiterStyleComment, "html", "<!-- ====== -->"},
+		{DelimiterStyleComment, "json", "======"},
+		{DelimiterStyleComment, "", "======"},
+		{DelimiterStyleMarkdown, "go", "\n---"},
+		{DelimiterStyleMarkdown, "", "\n---"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style+"/"+tt.language, func(t *testing.T) {
+			if got := renderDelimiter("======", tt.style, tt.language); got != tt.want {
+				t.Errorf("renderDelimiter(%q, %q) = %q, want %q", tt.style, tt.language, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDelimiterLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"======", true},
+		{"// ======", true},
+		{"# ======", true},
+		{"<!-- ====== -->", true},
+		{"//  ======", false},
+		{"# ====== x", false},
+		{"=====", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := isDelimiterLine(tt.line, "======"); got != tt.want {
+				t.Errorf("isDelimiterLine(%q) = %t, want %t", tt.line, got, tt.want)
+			}
+		})
+	}
+}
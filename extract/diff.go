@@ -0,0 +1,224 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// diffOp is one line of an edit script: kept (' '), deleted ('-') or inserted ('+').
+type diffOp struct {
+	Kind byte
+	Line string
+}
+
+// diffMaxCost bounds the edit distance diffLines searches for in one range of
+// lines, keeping large, very different inputs fast. A range needing more edits
+// is shown as deleted and inserted in full, which is still a valid diff.
+const diffMaxCost = 2048
+
+// diffLines returns an edit script turning a into b, the shortest one unless
+// a range exceeds diffMaxCost. It uses the linear space refinement of Myers'
+// O(ND) algorithm, so memory stays proportional to the input.
+func diffLines(a, b []string) []diffOp {
+	return appendDiff(nil, a, b)
+}
+
+// appendDiff appends the edit script turning a into b to ops, splitting the
+// problem at the middle snake of the shortest edit script.
+func appendDiff(ops []diffOp, a, b []string) []diffOp {
+	// Keep the common prefix and suffix out of the search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	ops = appendOps(ops, ' ', a[:prefix])
+	a, b = a[prefix:], b[prefix:]
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	if len(a) == 0 || len(b) == 0 {
+		ops = appendOps(ops, '-', a)
+		ops = appendOps(ops, '+', b)
+	} else if x, y, u, v, ok := middleSnake(a, b); !ok {
+		ops = appendOps(ops, '-', a)
+		ops = appendOps(ops, '+', b)
+	} else {
+		ops = appendDiff(ops, a[:x], b[:y])
+		ops = appendOps(ops, ' ', a[x:u])
+		ops = appendDiff(ops, a[u:], b[v:])
+	}
+	return appendOps(ops, ' ', common)
+}
+
+// appendOps appends an operation of kind for each line.
+func appendOps(ops []diffOp, kind byte, lines []string) []diffOp {
+	for _, line := range lines {
+		ops = append(ops, diffOp{Kind: kind, Line: line})
+	}
+	return ops
+}
+
+// middleSnake finds the middle snake of the shortest edit script turning a
+// into b, searching forward from the start and backward from the end at the
+// same time: a[x:u] equals b[y:v], and the edits before and after it are
+// each about half of the script. It reports false if the script needs more
+// than diffMaxCost edits in each direction.
+func middleSnake(a, b []string) (x, y, u, v int, ok bool) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := min((n+m+1)/2, diffMaxCost)
+	offset := maxD + 1
+	// forward[k] and backward[k] hold the furthest x reached on diagonal k,
+	// backward ones measured from the ends of a and b
+	forward := make([]int, 2*maxD+3)
+	backward := make([]int, 2*maxD+3)
+
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1] // Step down: insert from b
+			} else {
+				x = forward[offset+k-1] + 1 // Step right: delete from a
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			forward[offset+k] = x
+			if back := delta - k; odd && back >= -(d-1) && back <= d-1 && x+backward[offset+back] >= n {
+				return startX, startY, x, y, true
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x, y = x+1, y+1
+			}
+			backward[offset+k] = x
+			if fwd := delta - k; !odd && fwd >= -d && fwd <= d && x+forward[offset+fwd] >= n {
+				return n - x, m - y, n - startX, m - startY, true
+			}
+		}
+	}
+	return 0, 0, 0, 0, false
+}
+
+// splitDiffLines splits text into lines for diffing, ignoring a final newline.
+func splitDiffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// unifiedDiff returns a unified diff from oldText to newText with diffContext
+// lines of context, or an empty string when they are equal.
+func unifiedDiff(oldName, newName, oldText, newText string) string {
+	ops := diffLines(splitDiffLines(oldText), splitDiffLines(newText))
+
+	// Line positions in both texts before each operation
+	oldPos := make([]int, len(ops)+1)
+	newPos := make([]int, len(ops)+1)
+	var changes []int
+	for i, op := range ops {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if op.Kind != '+' {
+			oldPos[i+1]++
+		}
+		if op.Kind != '-' {
+			newPos[i+1]++
+		}
+		if op.Kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	for i := 0; i < len(changes); {
+		// Merge changes whose context would overlap into one hunk
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*diffContext+1 {
+			j++
+		}
+		start := max(changes[i]-diffContext, 0)
+		end := min(changes[j]+diffContext+1, len(ops))
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n",
+			hunkRange(oldPos[start], oldPos[end]-oldPos[start]),
+			hunkRange(newPos[start], newPos[end]-newPos[start]))
+		for _, op := range ops[start:end] {
+			b.WriteByte(op.Kind)
+			b.WriteString(op.Line + "\n")
+		}
+		i = j + 1
+	}
+	return b.String()
+}
+
+// hunkRange formats the start,count of a hunk side. Lines are numbered from
+// one; an empty side names the line before it, as diff -u does.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// readDiffSide reads one file of a -diff pair. A missing file is treated as
+// empty and named /dev/null, so a pair with one missing file shows it as
+// added or deleted.
+func readDiffSide(path string) (name, text string, err error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "/dev/null", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	text, _ = decodeText(content)
+	return path, text, nil
+}
+
+// fileDiff returns the unified diff between the files at oldPath and newPath.
+func fileDiff(oldPath, newPath string) (string, error) {
+	_, oldErr := os.Stat(oldPath)
+	_, newErr := os.Stat(newPath)
+	if errors.Is(oldErr, fs.ErrNotExist) && errors.Is(newErr, fs.ErrNotExist) {
+		return "", fmt.Errorf("neither %s nor %s exists", oldPath, newPath)
+	}
+	oldName, oldText, err := readDiffSide(oldPath)
+	if err != nil {
+		return "", err
+	}
+	newName, newText, err := readDiffSide(newPath)
+	if err != nil {
+		return "", err
+	}
+	return unifiedDiff(oldName, newName, oldText, newText), nil
+}
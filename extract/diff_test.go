@@ -0,0 +1,156 @@
+package extract
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// applyDiff returns the old and new lines described by ops, and the number of
+// inserted and deleted lines.
+func applyDiff(ops []diffOp) (a, b []string, edits int) {
+	for _, op := range ops {
+		if op.Kind != '+' {
+			a = append(a, op.Line)
+		}
+		if op.Kind != '-' {
+			b = append(b, op.Line)
+		}
+		if op.Kind != ' ' {
+			edits++
+		}
+	}
+	return a, b, edits
+}
+
+// editDistance returns the fewest inserted and deleted lines turning a into b.
+func editDistance(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	return len(a) + len(b) - 2*lcs[0][0]
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string // Lines as characters
+		want string // Operations as kind and line pairs
+	}{
+		{"equal", "abc", "abc", " a b c"},
+		{"both empty", "", "", ""},
+		{"insert all", "", "ab", "+a+b"},
+		{"delete all", "ab", "", "-a-b"},
+		{"insert middle", "ac", "abc", " a+b c"},
+		{"delete middle", "abc", "ac", " a-b c"},
+		{"replace", "abc", "axc", " a-b+x c"},
+		{"moved line", "abcd", "bcda", "-a b c d+a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got strings.Builder
+			for _, op := range diffLines(strings.Split(tt.a, ""), strings.Split(tt.b, "")) {
+				got.WriteByte(op.Kind)
+				got.WriteString(op.Line)
+			}
+			if got.String() != tt.want {
+				t.Errorf("diffLines(%q, %q) = %q, want %q", tt.a, tt.b, got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLinesMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, rng.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return lines
+	}
+	for i := range 2000 {
+		a, b := randomLines(), randomLines()
+		gotA, gotB, edits := applyDiff(diffLines(a, b))
+		if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+			t.Fatalf("case %d: diffLines(%q, %q) does not reproduce its inputs", i, a, b)
+		}
+		if want := editDistance(a, b); edits != want {
+			t.Fatalf("case %d: diffLines(%q, %q) makes %d edits, want %d", i, a, b, edits, want)
+		}
+	}
+}
+
+func TestDiffLinesLargeInput(t *testing.T) {
+	// Inputs differing more than diffMaxCost allows still produce a valid diff
+	a := make([]string, 8000)
+	b := make([]string, 8000)
+	for i := range a {
+		a[i] = fmt.Sprintf("old %d", i)
+		b[i] = fmt.Sprintf("new %d", i)
+	}
+	b[4000] = a[4000]
+	gotA, gotB, _ := applyDiff(diffLines(a, b))
+	if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+		t.Error("diffLines on large inputs does not reproduce its inputs")
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{
+			name: "one change",
+			old:  "1\n2\n3\n4\n5\n",
+			new:  "1\n2\nthree\n4\n5\n",
+			want: "--- old\n+++ new\n@@ -1,5 +1,5 @@\n 1\n 2\n-3\n+three\n 4\n 5\n",
+		},
+		{
+			name: "separate hunks",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			new:  "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			want: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		},
+		{
+			name: "merged hunks",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n",
+			new:  "one\n2\n3\n4\n5\n6\n7\neight\n",
+			want: "--- old\n+++ new\n@@ -1,8 +1,8 @@\n-1\n+one\n 2\n 3\n 4\n 5\n 6\n 7\n-8\n+eight\n",
+		},
+		{
+			name: "added file",
+			old:  "",
+			new:  "a\nb\n",
+			want: "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "deleted file",
+			old:  "a\n",
+			new:  "",
+			want: "--- old\n+++ new\n@@ -1 +0,0 @@\n-a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("old", "new", tt.old, tt.new); got != tt.want {
+				t.Errorf("unifiedDiff = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
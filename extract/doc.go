@@ -0,0 +1,27 @@
+// Package extract collects files into a single prompt-friendly document. It
+// holds the engine behind the go-file-extract command: configuration, flag
+// parsing and the extraction itself.
+//
+// A minimal program that extracts two files with the user's configuration:
+//
+//	configPath, err := extract.ResolveConfigPath(nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	app, err := extract.NewApp(configPath)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	opts, err := extract.ParseArguments([]string{"-files", "main.go", "go.mod"}, app.DefaultDelimiter(), app.Defaults())
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	output, err := app.Extract(*opts)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Print(output)
+//
+// ExtractTo writes the output to an io.Writer as each file is processed
+// instead, which keeps memory use flat for large extractions.
+package extract
@@ -0,0 +1,78 @@
+package extract
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// documentData is the data available to -template-file.
+type documentData struct {
+	Prepend   string          // The -prepend text, ending with a newline unless empty
+	Append    string          // The -append text, ending with a newline unless empty
+	Delimiter string          // The -delimiter text
+	TOC       string          // The -toc list, empty without it
+	Tree      string          // The -tree rendering, empty without it
+	Files     []documentFile  // The extracted files in output order
+	Batches   []documentBatch // Executables run once over their files with -exec-mode batch
+	Diffs     []documentDiff  // The -diff pairs
+}
+
+// documentFile is one extracted file in documentData. Files listed without
+// their content have Omitted or IdenticalTo set and an empty Content.
+type documentFile struct {
+	Path        string // The path shown in headers
+	Language    string // The language used in code fences
+	Content     string // The content after trimming, redaction and other processing
+	ExecOutput  string // Labelled executable output, empty in batch mode or without an executable
+	Omitted     bool   // Listed by -manifest-pattern
+	Size        int64  // The size of an omitted file in bytes
+	IdenticalTo string // The path of the earlier file with the same content, with -dedupe-content
+}
+
+// documentBatch is one executable run in -exec-mode batch.
+type documentBatch struct {
+	Command string
+	Paths   []string
+	Output  string // Labelled executable output
+}
+
+// documentDiff is one -diff pair.
+type documentDiff struct {
+	Old  string
+	New  string
+	Diff string // The unified diff, or "(no differences)"
+}
+
+// parseDocumentTemplate reads and compiles the -template-file template. The
+// fence function returns a code fence long enough for its argument, in the
+// -fence-style and -fence-len of opts. An empty path returns nil.
+func parseDocumentTemplate(path string, opts *Options) (*template.Template, error) {
+	if path == "" {
+		return nil, nil
+	}
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read -template-file: %v", err)
+	}
+	funcs := template.FuncMap{
+		"fence": func(content string) string {
+			return codeFence(content, fenceChar(opts.FenceStyle), opts.FenceLen)
+		},
+	}
+	tmpl, err := template.New("document").Funcs(funcs).Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("invalid -template-file: %v", err)
+	}
+	return tmpl, nil
+}
+
+// renderDocument executes the -template-file template with data.
+func renderDocument(tmpl *template.Template, data documentData) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render -template-file: %v", err)
+	}
+	return b.String(), nil
+}
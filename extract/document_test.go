@@ -0,0 +1,73 @@
+package extract
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// builtInLayout is the template from the README that reproduces the default
+// markdown layout.
+const builtInLayout = `{{.Prepend}}{{with .TOC}}{{.}}{{$.Delimiter}}
+{{end}}{{with .Tree}}{{.}}{{$.Delimiter}}
+{{end}}{{range .Files}}{{if .Omitted}}{{.Path}} (contents omitted, {{.Size}} bytes)
+{{else if .IdenticalTo}}{{.Path}} (identical to {{.IdenticalTo}})
+{{else}}{{.Path}}
+{{fence .Content}}{{.Language}}
+{{.Content}}
+{{fence .Content}}
+{{with .ExecOutput}}{{.}}
+{{end}}{{end}}{{$.Delimiter}}
+{{end}}{{range .Diffs}}Diff of {{.Old}} and {{.New}}
+{{fence .Diff}}diff
+{{.Diff}}{{fence .Diff}}
+{{$.Delimiter}}
+{{end}}{{range .Batches}}{{.Output}}
+{{$.Delimiter}}
+{{end}}{{.Append}}`
+
+func TestExtractTemplateFile(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go":         "package a\n",
+		"copy.go":      "package a\n",
+		"data.csv":     "a,b\n",
+		"old.txt":      "x\n",
+		"new.txt":      "y\n",
+		"layout.tmpl":  builtInLayout,
+		"nested/b.md":  "```sh\nmake\n```\n",
+		"nested/c.txt": "c\n",
+	})
+	path := func(name string) string { return filepath.Join(dir, name) }
+	files := []string{"-files", path("a.go"), path("copy.go"), path("data.csv"), path("nested")}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"files", nil},
+		{"sections", []string{"-toc", "-tree", "-manifest-pattern", `\.csv$`, "-dedupe-content", "-diff", path("old.txt"), path("new.txt")}},
+		{"per-file exec", []string{"-exec", `sh -c "echo out"`}},
+		{"batch exec", []string{"-exec", `sh -c "echo out"`, "-exec-mode", ExecModeBatch}},
+		{"prepend and append", []string{"-prepend", "Review this:", "-append", "Thanks."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append(files, tt.args...)
+			want, err := extractIn(t, dir, args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := extractIn(t, dir, append(args, "-template-file", path("layout.tmpl"))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("template output = %q, want the built-in layout %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,436 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// DefaultExecTimeout bounds how long a single executable may run.
+const DefaultExecTimeout = 30 * time.Second
+
+// DefaultExecMaxOutput caps how many bytes are captured from each output stream of an executable.
+const DefaultExecMaxOutput = 1 << 20
+
+// Values accepted by -exec-mode.
+const (
+	ExecModePerFile = "per-file" // Run the executable once for every file
+	ExecModeBatch   = "batch"    // Run the executable once with all of its files appended
+	ExecModeReplace = "replace"  // Run the executable per file on its content and show its stdout instead
+)
+
+var execModes = []string{ExecModePerFile, ExecModeBatch, ExecModeReplace}
+
+// Values accepted by -exec-output-position.
+const (
+	ExecOutputAfter    = "after"    // Write executable output after the file content
+	ExecOutputBefore   = "before"   // Write executable output before the file content
+	ExecOutputSeparate = "separate" // Collect executable output in one section after all files
+)
+
+var execOutputPositions = []string{ExecOutputAfter, ExecOutputBefore, ExecOutputSeparate}
+
+// FilePlaceholder marks where file paths go in an executable command.
+const FilePlaceholder = "{file}"
+
+// DefaultExecLabelTemplate introduces each executable's output with the command that produced it.
+const DefaultExecLabelTemplate = "--- output of `{{.Command}}` ---"
+
+// execLabel is the data available to -exec-label-template.
+type execLabel struct {
+	Command string // The executable command as configured
+	Path    string // The file the command ran on; in batch mode, all files separated by spaces
+}
+
+// parseExecLabelTemplate compiles a -exec-label-template value and renders it
+// once so unknown fields are reported before any executable runs. An empty
+// template turns labels off and returns nil.
+func parseExecLabelTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("exec-label").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -exec-label-template: %v", err)
+	}
+	if err := tmpl.Execute(io.Discard, execLabel{Command: "gofmt -l", Path: "main.go"}); err != nil {
+		return nil, fmt.Errorf("invalid -exec-label-template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// labelExecOutput puts the rendered label on its own line before non-empty
+// executable output. Output is returned unchanged when tmpl is nil.
+func labelExecOutput(tmpl *template.Template, output string, label execLabel) (string, error) {
+	if tmpl == nil || output == "" {
+		return output, nil
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, label); err != nil {
+		return "", fmt.Errorf("failed to render -exec-label-template for %s: %v", label.Path, err)
+	}
+	return b.String() + "\n" + output, nil
+}
+
+// execSettings controls how executables are run and how their output is captured.
+type execSettings struct {
+	Timeout       time.Duration // Zero disables the timeout
+	MaxOutput     int           // Bytes kept per stream; zero keeps everything
+	IncludeStderr bool
+	Retries       int                              // Extra attempts after a failed or timed out run
+	Verbosef      func(format string, args ...any) // Reports retries; may be nil
+	Stdin         []byte                           // Fed to the executable instead of appending the file paths; nil appends them
+}
+
+// execWaitDelay bounds how long a run waits for its output pipes to close
+// once the command has exited or been killed. A process it started in the
+// background may otherwise keep them open indefinitely.
+const execWaitDelay = time.Second
+
+// execRetryBackoff is the wait before the first retry; each later retry waits one step longer.
+const execRetryBackoff = 500 * time.Millisecond
+
+// ExecError reports an executable that failed or timed out.
+type ExecError struct {
+	Err error
+
+	retryable bool // The command ran but exited non-zero or timed out
+	notFound  bool // The command is not installed or not on PATH
+}
+
+func (e *ExecError) Error() string { return e.Err.Error() }
+func (e *ExecError) Unwrap() error { return e.Err }
+
+// cappedBuffer keeps at most limit bytes written to it and counts the rest.
+type cappedBuffer struct {
+	buf     bytes.Buffer
+	limit   int
+	dropped int
+}
+
+// Write implements io.Writer, discarding bytes beyond the limit.
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if b.limit > 0 {
+		room := max(b.limit-b.buf.Len(), 0)
+		if len(p) > room {
+			b.dropped += len(p) - room
+			p = p[:room]
+		}
+	}
+	b.buf.Write(p)
+	return n, nil
+}
+
+// String returns the captured output, noting how many bytes were truncated.
+func (b *cappedBuffer) String() string {
+	if b.dropped == 0 {
+		return b.buf.String()
+	}
+	return fmt.Sprintf("%s\n... [truncated %d bytes]\n", b.buf.String(), b.dropped)
+}
+
+// runExecutable runs the executable command on the file paths and returns its
+// stdout, followed by its stderr unless excluded. Runs that exit non-zero or
+// time out are retried up to settings.Retries times with a growing backoff;
+// commands that cannot be started, e.g. because they are not installed, fail
+// immediately.
+func runExecutable(ctx context.Context, executable string, filePaths []string, settings execSettings) (string, error) {
+	for attempt := 1; ; attempt++ {
+		output, err := runExecutableOnce(ctx, executable, filePaths, settings)
+		var execErr *ExecError
+		if err == nil || attempt > settings.Retries || !errors.As(err, &execErr) || !execErr.retryable {
+			return output, err
+		}
+
+		backoff := time.Duration(attempt) * execRetryBackoff
+		if settings.Verbosef != nil {
+			settings.Verbosef("Retrying '%s' in %s (attempt %d of %d): %v", executable, backoff, attempt+1, settings.Retries+1, err)
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// runExecutableOnce runs the executable command a single time for runExecutable.
+func runExecutableOnce(ctx context.Context, executable string, filePaths []string, settings execSettings) (string, error) {
+	// Split the executable and its arguments
+	parts, err := tokenizeCommand(executable)
+	if err != nil {
+		return "", fmt.Errorf("invalid executable command: %s: %v", executable, err)
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("invalid executable command: %s", executable)
+	}
+
+	runCtx := ctx
+	if settings.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, settings.Timeout)
+		defer cancel()
+	}
+
+	stdout := &cappedBuffer{limit: settings.MaxOutput}
+	stderr := &cappedBuffer{limit: settings.MaxOutput}
+	cmd := exec.CommandContext(runCtx, parts[0], expandFilePlaceholder(parts[1:], filePaths, settings.Stdin == nil)...)
+	if settings.Stdin != nil {
+		cmd.Stdin = bytes.NewReader(settings.Stdin)
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = execWaitDelay
+	killProcessGroup(cmd)
+	err = cmd.Run()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		err = nil // The command succeeded but left a background process holding its output; keep what was captured
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", &ExecError{Err: fmt.Errorf("executable '%s' not found on PATH", parts[0]), notFound: true}
+	}
+	filePath := strings.Join(filePaths, "', '")
+	if ctx.Err() != nil {
+		// The extraction was cancelled rather than the executable failing
+		return "", ctx.Err()
+	}
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return "", &ExecError{Err: fmt.Errorf("executable '%s' timed out after %s on file '%s'", executable, settings.Timeout, filePath), retryable: true}
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		return "", &ExecError{
+			Err:       fmt.Errorf("failed to run executable '%s' with file '%s': %v\nOutput: %s%s", executable, filePath, err, stdout, stderr),
+			retryable: errors.As(err, &exitErr),
+		}
+	}
+
+	if !settings.IncludeStderr {
+		return stdout.String(), nil
+	}
+	return stdout.String() + stderr.String(), nil
+}
+
+// execJob is one run of a per-file executable.
+type execJob struct {
+	Executable string
+	Path       string
+}
+
+// runExecJobs runs jobs with run on up to workers goroutines and returns their
+// outputs indexed like jobs, so callers can write them in a fixed order
+// whatever order the runs finish in. The first failure cancels the runs still
+// going and is returned.
+func runExecJobs(ctx context.Context, jobs []execJob, workers int, run func(ctx context.Context, job execJob) (string, error)) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	outputs := make([]string, len(jobs))
+	var mu sync.Mutex
+	var firstErr error
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				output, err := run(ctx, jobs[i])
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+					mu.Unlock()
+					continue
+				}
+				outputs[i] = output
+			}
+		}()
+	}
+
+feed:
+	for i := range jobs {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return outputs, ctx.Err()
+}
+
+// runPostExec runs the -post-exec command with input on its stdin and returns
+// its stdout. Unlike file executables its output is never capped, since it
+// replaces the whole result.
+func runPostExec(ctx context.Context, command, input string, settings execSettings) (string, error) {
+	parts, err := tokenizeCommand(command)
+	if err != nil {
+		return "", fmt.Errorf("invalid -post-exec command: %s: %v", command, err)
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("invalid -post-exec command: %s", command)
+	}
+
+	runCtx := ctx
+	if settings.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, settings.Timeout)
+		defer cancel()
+	}
+
+	var stdout bytes.Buffer
+	stderr := &cappedBuffer{limit: settings.MaxOutput}
+	cmd := exec.CommandContext(runCtx, parts[0], parts[1:]...)
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = execWaitDelay
+	killProcessGroup(cmd)
+	err = cmd.Run()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		err = nil
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", &ExecError{Err: fmt.Errorf("executable '%s' not found on PATH; passed with -post-exec", parts[0]), notFound: true}
+	}
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return "", &ExecError{Err: fmt.Errorf("-post-exec command '%s' timed out after %s", command, settings.Timeout)}
+	}
+	if err != nil {
+		if output := stderr.String(); output != "" {
+			err = fmt.Errorf("%v\nOutput: %s", err, output)
+		}
+		return "", &ExecError{Err: fmt.Errorf("-post-exec command '%s' failed: %v", command, err)}
+	}
+	return stdout.String(), nil
+}
+
+// execOrigin describes where an executable was configured, for errors about
+// missing executables: the -exec flag, or the extensions it is set for in the
+// merged file type executables.
+func execOrigin(executable, execCommand string, fileTypeExecutables map[string]string) string {
+	if executable == execCommand {
+		return "passed with -exec"
+	}
+	var exts []string
+	for ext, cmd := range fileTypeExecutables {
+		if cmd == executable {
+			exts = append(exts, ext)
+		}
+	}
+	slices.Sort(exts)
+	return fmt.Sprintf("configured for %s files", strings.Join(exts, ", "))
+}
+
+// withExecOrigin adds origin to err if it reports a missing executable.
+func withExecOrigin(err error, origin string) error {
+	var execErr *ExecError
+	if errors.As(err, &execErr) && execErr.notFound {
+		execErr.Err = fmt.Errorf("%v; %s", execErr.Err, origin)
+	}
+	return err
+}
+
+// expandFilePlaceholder substitutes {file} in the arguments with the file paths,
+// repeating such an argument once per path. Without a placeholder the paths are
+// appended to the arguments if appendPaths is set.
+func expandFilePlaceholder(args, filePaths []string, appendPaths bool) []string {
+	var expanded []string
+	hasPlaceholder := false
+	for _, arg := range args {
+		if !strings.Contains(arg, FilePlaceholder) {
+			expanded = append(expanded, arg)
+			continue
+		}
+		hasPlaceholder = true
+		for _, filePath := range filePaths {
+			expanded = append(expanded, strings.ReplaceAll(arg, FilePlaceholder, filePath))
+		}
+	}
+	if !hasPlaceholder && appendPaths {
+		expanded = append(expanded, filePaths...)
+	}
+	return expanded
+}
+
+// tokenizeCommand splits a command line into arguments like a POSIX shell would,
+// honouring single quotes, double quotes and backslash escapes. It does not
+// perform any expansion.
+func tokenizeCommand(s string) ([]string, error) {
+	var tokens []string
+	var current strings.Builder
+	inToken := false
+	var quote rune // The open quote character, or zero outside quotes
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			// Inside double quotes a backslash only escapes characters that are special there
+			if quote == '"' && r != '"' && r != '\\' && r != '$' && r != '`' {
+				current.WriteRune('\\')
+			}
+			current.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inToken = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inToken = true
+		case unicode.IsSpace(r):
+			if inToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inToken {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
@@ -0,0 +1,209 @@
+package extract
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExpandFilePlaceholder(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		filePaths   []string
+		appendPaths bool
+		want        []string
+	}{
+		{"appended", []string{"-l"}, []string{"a.go", "b.go"}, true, []string{"-l", "a.go", "b.go"}},
+		{"placeholder", []string{"{file}", "-l"}, []string{"a.go"}, true, []string{"a.go", "-l"}},
+		{"placeholder per file", []string{"-x", "{file}", "-y"}, []string{"a.go", "b.go"}, true, []string{"-x", "a.go", "b.go", "-y"}},
+		{"placeholder inside argument", []string{"--path={file}"}, []string{"a.go", "b.go"}, true, []string{"--path=a.go", "--path=b.go"}},
+		{"not appended", []string{"-l"}, []string{"a.go"}, false, []string{"-l"}},
+		{"placeholder without appending", []string{"{file}"}, []string{"a.go"}, false, []string{"a.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expandFilePlaceholder(tt.args, tt.filePaths, tt.appendPaths)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expandFilePlaceholder(%q, %q, %t) = %q, want %q", tt.args, tt.filePaths, tt.appendPaths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunExecutableFilePlaceholder(t *testing.T) {
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo is not available")
+	}
+	tests := []struct {
+		executable string
+		want       string
+	}{
+		{"echo first", "first a.go\n"},
+		{"echo {file} last", "a.go last\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.executable, func(t *testing.T) {
+			got, err := runExecutable(context.Background(), tt.executable, []string{"a.go"}, execSettings{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("runExecutable(%q) = %q, want %q", tt.executable, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunExecutableTimeout(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep is not available")
+	}
+	settings := execSettings{Timeout: 200 * time.Millisecond}
+	tests := []struct {
+		name    string
+		run     func() (string, error)
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "forked child outlives the timeout",
+			run: func() (string, error) {
+				return runExecutable(context.Background(), `sh -c "sleep 3; echo hi" x`, []string{"a.go"}, settings)
+			},
+			wantErr: true,
+		},
+		{
+			name: "background child holds the output",
+			run: func() (string, error) {
+				return runExecutable(context.Background(), `sh -c "(sleep 3) & echo hi" x`, []string{"a.go"}, execSettings{})
+			},
+			want: "hi\n",
+		},
+		{
+			name: "post-exec forked child outlives the timeout",
+			run: func() (string, error) {
+				return runPostExec(context.Background(), `sh -c "sleep 3; cat"`, "input", settings)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			got, err := tt.run()
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("run returned after %s, want the timeout enforced", elapsed)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("run error = %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("run = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		wantErr bool
+	}{
+		{command: "", want: nil},
+		{command: "gofmt -l", want: []string{"gofmt", "-l"}},
+		{command: "  gofmt \t -l  ", want: []string{"gofmt", "-l"}},
+		{command: `cat "My Documents/a.go"`, want: []string{"cat", "My Documents/a.go"}},
+		{command: `cat 'My Documents/a.go'`, want: []string{"cat", "My Documents/a.go"}},
+		{command: `cat My\ Documents/a.go`, want: []string{"cat", "My Documents/a.go"}},
+		{command: `echo "say \"hi\""`, want: []string{"echo", `say "hi"`}},
+		{command: `echo 'it'\''s'`, want: []string{"echo", "it's"}},
+		{command: `echo "a\nb"`, want: []string{"echo", `a\nb`}},
+		{command: `echo 'a\"b'`, want: []string{"echo", `a\"b`}},
+		{command: `echo "" ''`, want: []string{"echo", "", ""}},
+		{command: `grep -e "a b"c`, want: []string{"grep", "-e", "a bc"}},
+		{command: `echo "open`, wantErr: true},
+		{command: `echo 'open`, wantErr: true},
+		{command: `echo \`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got, err := tokenizeCommand(tt.command)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("tokenizeCommand(%q) error = %v, want error %t", tt.command, err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("tokenizeCommand(%q) = %q, want %q", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelExecOutput(t *testing.T) {
+	label := execLabel{Command: "gofmt -l", Path: "a.go b.go"}
+	tests := []struct {
+		name     string
+		template string
+		output   string
+		want     string
+	}{
+		{"default", DefaultExecLabelTemplate, "a.go\n", "--- output of `gofmt -l` ---\na.go\n"},
+		{"path", "# {{.Command}} on {{.Path}}", "ok\n", "# gofmt -l on a.go b.go\nok\n"},
+		{"no output", DefaultExecLabelTemplate, "", ""},
+		{"no label", "", "ok\n", "ok\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseExecLabelTemplate(tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := labelExecOutput(tmpl, tt.output, label)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("labelExecOutput(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExecLabelTemplateErrors(t *testing.T) {
+	for _, text := range []string{"{{.Command", "{{.Missing}}"} {
+		if _, err := parseExecLabelTemplate(text); err == nil {
+			t.Errorf("parseExecLabelTemplate(%q) succeeded, want an error", text)
+		}
+	}
+}
+
+// BenchmarkRunExecJobs runs a fake executable that sleeps, so the time per
+// operation shows how much -jobs overlaps the runs.
+func BenchmarkRunExecJobs(b *testing.B) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		b.Skip("sleep is not available")
+	}
+	jobs := make([]execJob, 16)
+	for i := range jobs {
+		jobs[i] = execJob{Executable: "sleep 0.01", Path: fmt.Sprintf("file%d.go", i)}
+	}
+	run := func(ctx context.Context, job execJob) (string, error) {
+		return runExecutable(ctx, job.Executable, nil, execSettings{})
+	}
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("jobs=%d", workers), func(b *testing.B) {
+			for range b.N {
+				if _, err := runExecJobs(context.Background(), jobs, workers, run); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,1018 @@
+package extract
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// sourceFile is a single file to extract: on disk, inside an archive or fetched by URL.
+type sourceFile struct {
+	Path     string    // Path shown in the output header
+	Content  []byte    // Content of archive entries and remote files; nil for files on disk
+	ModTime  time.Time // Modification time of archive entries and remote files
+	InMemory bool      // Content holds the file; there is no path on disk to read or pass to executables
+	NoCache  bool      // A secret file or one matching -force-include, kept out of the cache
+}
+
+// dedupeFiles removes repeated entries from files, keeping the first
+// occurrence. Paths are compared by their absolute, symlink-resolved form so
+// "a.go", "./a.go" and a link to a.go count as the same file.
+func dedupeFiles(files []string) []string {
+	seen := make(map[string]bool, len(files))
+	var unique []string
+	for _, filePath := range files {
+		key, err := filepath.Abs(filePath)
+		if err != nil {
+			key = filePath
+		}
+		if resolved, err := filepath.EvalSymlinks(key); err == nil {
+			key = resolved
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, filePath)
+	}
+	return unique
+}
+
+// expandFiles turns the requested paths into source files, expanding archives
+// into their entries, or into the entries selected after ArchiveSeparator, and
+// fetching URLs when opts.AllowRemote is set. Archives and URLs that could not
+// be read and selectors matching no entry are returned as errors.
+func expandFiles(ctx context.Context, files []string, opts *Options) ([]sourceFile, []fileError) {
+	var sources []sourceFile
+	var errs []fileError
+	for _, filePath := range files {
+		if isRemote(filePath) {
+			if !opts.AllowRemote {
+				errs = append(errs, fileError{Path: filePath, Err: errors.New("remote files are only fetched with -allow-remote")})
+				continue
+			}
+			source, err := fetchRemote(ctx, filePath, opts.Timeout)
+			if err != nil {
+				errs = append(errs, fileError{Path: filePath, Err: err})
+				continue
+			}
+			sources = append(sources, source)
+			continue
+		}
+		archive, selector, selected := splitArchivePath(filePath)
+		if !selected && !isArchive(filePath) {
+			sources = append(sources, sourceFile{Path: filePath})
+			continue
+		}
+		entries, err := readArchive(archive)
+		if err != nil {
+			errs = append(errs, fileError{Path: filePath, Err: err})
+			continue
+		}
+		if selected {
+			entries = selectEntries(entries, selector)
+			if len(entries) == 0 {
+				errs = append(errs, fileError{Path: filePath, Err: fmt.Errorf("no entry matches %s in %s", selector, archive)})
+				continue
+			}
+		}
+		sources = append(sources, entries...)
+	}
+	return sources, errs
+}
+
+// fileError records a file or directory that could not be extracted.
+type fileError struct {
+	Path string
+	Err  error
+}
+
+func (e fileError) Error() string { return e.Path + ": " + e.Err.Error() }
+
+// checkFileErrors fails with every file error when -strict is set, and
+// otherwise reports them as warnings unless -quiet is set.
+func checkFileErrors(fileErrs []fileError, opts *Options) error {
+	if len(fileErrs) == 0 {
+		return nil
+	}
+	if opts.Strict {
+		errs := make([]error, len(fileErrs))
+		for i, fileErr := range fileErrs {
+			errs[i] = fileErr
+		}
+		return fmt.Errorf("%d file(s) could not be read and -strict is set:\n%w", len(fileErrs), errors.Join(errs...))
+	}
+	if !opts.Quiet {
+		reportFileErrors(fileErrs)
+	}
+	return nil
+}
+
+// reportFileErrors logs every file error in one block, with a count of the
+// files that were not found.
+func reportFileErrors(errs []fileError) {
+	missing := 0
+	var b strings.Builder
+	for _, fileErr := range errs {
+		if errors.Is(fileErr.Err, fs.ErrNotExist) {
+			missing++
+		}
+		b.WriteString("\n  " + fileErr.Error())
+	}
+	log.Printf("Warning: %d file(s) could not be extracted (%d not found):%s", len(errs), missing, b.String())
+}
+
+// matchesAny reports whether any of the regexes matches the path.
+func matchesAny(regexes []*regexp.Regexp, path string) bool {
+	for _, re := range regexes {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerPath returns the path shown for a file in headers and the tree. With
+// -relative or -base-dir, paths on disk are shown relative to the base
+// directory, falling back to the path as given if no relative path exists.
+func headerPath(source sourceFile, opts *Options) string {
+	if !(opts.Relative || opts.BaseDir != ".") || source.InMemory {
+		return source.Path
+	}
+	absPath, err := filepath.Abs(source.Path)
+	if err != nil {
+		return source.Path
+	}
+	absBase, err := filepath.Abs(opts.BaseDir)
+	if err != nil {
+		return source.Path
+	}
+	relPath, err := filepath.Rel(absBase, absPath)
+	if err != nil {
+		return source.Path
+	}
+	return relPath
+}
+
+// filePerms describes the permission bits and, on Unix, the owner and group of
+// a file, e.g. "-rw-r--r-- alice:staff". It is empty for files not on disk.
+func filePerms(source sourceFile) string {
+	if source.InMemory {
+		return ""
+	}
+	info, err := os.Stat(source.Path)
+	if err != nil {
+		return ""
+	}
+	perms := info.Mode().String()
+	if owner := fileOwner(info); owner != "" {
+		perms += " " + owner
+	}
+	return perms
+}
+
+// fileSection is one file's part of the output: its header line, its
+// content, fenced when -wrap-code is set, and any executable output.
+type fileSection struct {
+	Header     string
+	Body       string
+	ExecOutput string
+}
+
+// writeFileSection writes section to output followed by the delimiter, placing
+// the executable output as position says. With ExecOutputSeparate the
+// executable output is left for the caller to write after all files; any
+// other position, including an empty one, means ExecOutputAfter.
+func writeFileSection(output *outputWriter, section fileSection, position, delimiter string) {
+	output.WriteString(section.Header + "\n")
+	if section.ExecOutput != "" && position == ExecOutputBefore {
+		output.WriteString(section.ExecOutput + "\n")
+	}
+	output.WriteString(section.Body)
+	if section.ExecOutput != "" && position != ExecOutputBefore && position != ExecOutputSeparate {
+		output.WriteString(section.ExecOutput + "\n")
+	}
+	output.WriteDelimiter(delimiter)
+}
+
+// fileMetadata describes the size and modification time of a file, e.g.
+// "1234 bytes, modified 2024-05-01T10:00:00Z". It returns "" if the file
+// cannot be stat'd.
+func fileMetadata(source sourceFile) string {
+	size, modTime := int64(len(source.Content)), source.ModTime
+	if !source.InMemory {
+		info, err := os.Stat(source.Path)
+		if err != nil {
+			return ""
+		}
+		size, modTime = info.Size(), info.ModTime()
+	}
+	return fmt.Sprintf("%d bytes, modified %s", size, modTime.UTC().Format(time.RFC3339))
+}
+
+// contentHash identifies file content for -dedupe-content.
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Extract reads, filters and formats the files selected by opts using the
+// app's configuration, and returns the output without writing it anywhere.
+// opts normally comes from ParseArguments so unset flags have their defaults.
+func (app *App) Extract(opts Options) (string, error) {
+	return app.ExtractContext(context.Background(), opts)
+}
+
+// ExtractContext is like Extract but stops between files and running
+// executables once ctx is done, returning ctx.Err().
+func (app *App) ExtractContext(ctx context.Context, opts Options) (string, error) {
+	var output strings.Builder
+	if err := app.ExtractTo(ctx, &output, opts); err != nil {
+		return "", err
+	}
+	return output.String(), nil
+}
+
+// ExtractTo is like ExtractContext but writes the output to w as each file is
+// processed instead of returning it, so memory use does not grow with the
+// output. On error, w may hold part of the output. -template-file and
+// -post-exec need the whole output and still assemble it in memory.
+func (app *App) ExtractTo(ctx context.Context, w io.Writer, opts Options) error {
+	// Show headers and match ignore files relative to the repository root,
+	// keeping the base directory outside a repository
+	if opts.RelativeToGitRoot {
+		if root := gitRoot(opts.BaseDir); root != "" {
+			opts.BaseDir = root
+		}
+		opts.Relative = true
+	}
+	return getData(ctx, w, &opts, app.fileTypeExecutables(), app.redactPatterns(), app.secretFiles(), app.generatedPatterns(), app.ignoreRules(opts.BaseDir, !opts.IgnoreGitIgnore, opts.RespectGitAttributes))
+}
+
+// getData processes files, runs executables, and writes the output to w.
+func getData(ctx context.Context, w io.Writer, opts *Options, fileTypeExecutables map[string]string, redactPatterns, secretFiles, generatedPatterns []string, ignores *ignoreRules) error {
+	// Resolve the text surrounding the file contents
+	prefix, err := readTextArgument(opts.Prepend)
+	if err != nil {
+		return fmt.Errorf("failed to read -prepend text: %v", err)
+	}
+	suffix, err := readTextArgument(opts.Append)
+	if err != nil {
+		return fmt.Errorf("failed to read -append text: %v", err)
+	}
+	documentTemplate, err := parseDocumentTemplate(opts.TemplateFile, opts)
+	if err != nil {
+		return err
+	}
+
+	// Compile regex for ignore pattern
+	var ignoreRegex *regexp.Regexp
+	if opts.IgnorePattern != "" {
+		var err error
+		ignoreRegex, err = regexp.Compile(opts.IgnorePattern)
+		if err != nil {
+			return fmt.Errorf("invalid regex pattern: %v", err)
+		}
+	}
+
+	// Compile regexes for include patterns; a file is kept if any of them matches
+	var includeRegexes []*regexp.Regexp
+	for _, pattern := range opts.IncludePatterns {
+		includeRegex, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid include pattern: %v", err)
+		}
+		includeRegexes = append(includeRegexes, includeRegex)
+	}
+
+	// Compile the markers of generated files for -skip-generated
+	var generatedMarkers []*regexp.Regexp
+	if opts.SkipGenerated {
+		generatedMarkers, err = compileGeneratedPatterns(generatedPatterns)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Compile regexes for -force-include; matching files bypass .gitignore and the secret file check
+	var forceIncludeRegexes []*regexp.Regexp
+	for _, pattern := range opts.ForceIncludePatterns {
+		forceIncludeRegex, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid force-include pattern: %v", err)
+		}
+		forceIncludeRegexes = append(forceIncludeRegexes, forceIncludeRegex)
+	}
+
+	// Compile regex for the lines to keep with -match
+	var matchRegex *regexp.Regexp
+	if opts.Match != "" {
+		matchRegex, err = regexp.Compile(opts.Match)
+		if err != nil {
+			return fmt.Errorf("invalid match pattern: %v", err)
+		}
+	}
+
+	// Compile regex for files listed without their content
+	var manifestRegex *regexp.Regexp
+	if opts.ManifestPattern != "" {
+		manifestRegex, err = regexp.Compile(opts.ManifestPattern)
+		if err != nil {
+			return fmt.Errorf("invalid manifest pattern: %v", err)
+		}
+	}
+
+	// Compile the code fence info string template
+	fenceInfoTemplate, err := parseFenceInfoTemplate(opts.FenceInfoTemplate)
+	if err != nil {
+		return err
+	}
+	execLabelTemplate, err := parseExecLabelTemplate(opts.ExecLabelTemplate)
+	if err != nil {
+		return err
+	}
+
+	// Prepare secret redaction
+	var secrets *redactor
+	if opts.Redact {
+		secrets, err = newRedactor(redactPatterns)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Merge FileTypeExecutables from config and command-line overrides
+	finalFileTypeExecutables := make(map[string]string)
+	for ext, cmd := range fileTypeExecutables {
+		finalFileTypeExecutables[ext] = cmd
+	}
+	for ext, cmd := range opts.FileExecs {
+		finalFileTypeExecutables[ext] = cmd
+	}
+
+	// executableFor returns the executable command for a file: the -exec
+	// override if provided, otherwise the one for its extension in the merged map
+	executableFor := func(filePath string) string {
+		if opts.ExecCommand != "" {
+			return opts.ExecCommand
+		}
+		return finalFileTypeExecutables[filepath.Ext(filePath)]
+	}
+
+	// Report why each file was included or skipped when verbose
+	verbosef := func(format string, args ...any) {
+		if opts.Verbose {
+			log.Printf(format, args...)
+		}
+	}
+
+	// Render the delimiter after a section in language, as -delimiter-style says
+	delimiter := func(language string) string {
+		return renderDelimiter(opts.Delimiter, opts.DelimiterStyle, language)
+	}
+
+	// Filter the files to extract
+	var included []sourceFile
+	walk := walkOptions{
+		MaxDepth:       opts.MaxDepth,
+		ExcludeDirs:    opts.ExcludeDirs,
+		FollowSymlinks: opts.FollowSymlinks,
+		Hidden:         opts.Hidden,
+		Verbosef:       verbosef,
+	}
+	files := opts.Files
+	if opts.Preset != "" {
+		preset, err := presetFiles(".", opts.Preset)
+		if err != nil {
+			return err
+		}
+		files = slices.Concat(files, preset)
+	}
+	paths, fileErrs := expandDirs(files, walk)
+	sources, archiveErrs := expandFiles(ctx, dedupeFiles(paths), opts)
+	fileErrs = append(fileErrs, archiveErrs...)
+	for _, source := range sources {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		filePath := source.Path
+
+		// Check if file is inside an excluded directory
+		if dir := excludedDir(filePath, opts.ExcludeDirs); dir != "" {
+			verbosef("Skipping %s: inside excluded directory %s", filePath, dir)
+			continue
+		}
+
+		// Check if file should be ignored by regex
+		if ignoreRegex != nil && ignoreRegex.MatchString(filePath) {
+			verbosef("Skipping %s: matches -ignore-pattern", filePath)
+			continue
+		}
+
+		// Check if file is outside the include allowlist
+		if len(includeRegexes) > 0 && !matchesAny(includeRegexes, filePath) {
+			verbosef("Skipping %s: matches no -include-pattern", filePath)
+			continue
+		}
+
+		forced := matchesAny(forceIncludeRegexes, filePath)
+		secret := isSecretFile(filePath, secretFiles)
+		source.NoCache = forced || secret
+
+		// Check if file looks like it holds credentials
+		if !opts.IncludeSecrets && !forced && secret {
+			if !opts.Quiet {
+				log.Printf("Warning: skipping %s because it may contain secrets; pass -include-secrets to extract it", filePath)
+			}
+			continue
+		}
+
+		// Check if file should be ignored by .gitignore or .extractignore
+		ignoredBy, err := ignores.Match(filePath)
+		if err != nil {
+			fileErrs = append(fileErrs, fileError{Path: filePath, Err: fmt.Errorf("failed to get relative path: %v", err)})
+			continue
+		}
+		if ignoredBy != "" {
+			if !forced {
+				verbosef("Skipping %s: ignored by %s", filePath, ignoredBy)
+				continue
+			}
+			verbosef("Including %s despite %s: matches -force-include", filePath, ignoredBy)
+		}
+
+		verbosef("Including %s", filePath)
+		included = append(included, source)
+	}
+	sortFiles(included, opts.Sort)
+
+	// skipContent reports whether a file is left out for its original content:
+	// generated with -skip-generated, or minified without -include-minified.
+	// With report unset nothing is logged, for the -jobs prepass.
+	skipContent := func(filePath string, content []byte, report bool) bool {
+		if opts.SkipGenerated && isGenerated(content, generatedMarkers) {
+			if report && !opts.Quiet {
+				log.Printf("Skipping %s because it is generated; drop -skip-generated to extract it", filePath)
+			}
+			return true
+		}
+		if !opts.IncludeMinified && isLikelyMinified(content) {
+			if report && !opts.Quiet {
+				log.Printf("Warning: skipping %s because it looks minified; pass -include-minified to extract it", filePath)
+			}
+			return true
+		}
+		return false
+	}
+
+	// Report totals instead of the file contents when -count is set, leaving
+	// out the files extraction would skip for their content
+	if opts.Count {
+		stats, countErrs := countFiles(included, func(filePath string, content []byte) bool {
+			return skipContent(filePath, content, true)
+		}, opts.DedupeContent)
+		fileErrs = append(fileErrs, countErrs...)
+		if err := checkFileErrors(fileErrs, opts); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, formatStats(stats))
+		return err
+	}
+
+	// Lay out the whole document from the processed files at the end with
+	// -template-file or -format xml, instead of writing each file as it is done
+	var renderLayout func(data documentData) (string, error)
+	switch {
+	case documentTemplate != nil:
+		renderLayout = func(data documentData) (string, error) {
+			return renderDocument(documentTemplate, data)
+		}
+	case opts.Format == FormatXML:
+		renderLayout = func(data documentData) (string, error) {
+			return renderXML(data, opts.LineNumbers, opts.ExecOutputPosition == ExecOutputSeparate), nil
+		}
+	}
+
+	// Write the output as it is assembled. -post-exec needs all of it first,
+	// and with a layout renderer the built-in layout is discarded.
+	target := w
+	var unprocessed strings.Builder
+	if opts.PostExec != "" {
+		target = &unprocessed
+	}
+	output := &outputWriter{w: target}
+	if renderLayout != nil {
+		output.w = io.Discard
+	}
+	if prefix != "" {
+		output.WriteString(withTrailingNewline(prefix))
+	}
+
+	// Keep all files in one unnamed group unless grouping by language
+	groups := []languageGroup{{Files: included}}
+	if opts.GroupByLanguage {
+		groups = groupByLanguage(included)
+	}
+
+	// Sections, files and batch runs passed to -template-file and -format xml
+	var data documentData
+
+	// List the included files in output order
+	if opts.TOC {
+		var paths []string
+		for _, group := range groups {
+			for _, source := range group.Files {
+				paths = append(paths, headerPath(source, opts))
+			}
+		}
+		data.TOC = formatTOC(paths)
+		output.WriteString(data.TOC)
+		output.WriteDelimiter(delimiter(""))
+	}
+
+	// Render the directory tree of the included files
+	if opts.Tree {
+		paths := make([]string, len(included))
+		for i, source := range included {
+			paths[i] = headerPath(source, opts)
+		}
+		data.Tree = renderTree(paths)
+		output.WriteString(data.Tree)
+		output.WriteDelimiter(delimiter(""))
+	}
+
+	settings := execSettings{
+		Timeout:       opts.ExecTimeout,
+		MaxOutput:     opts.ExecMaxOutput,
+		IncludeStderr: opts.ExecStderr,
+		Retries:       opts.ExecRetries,
+		Verbosef:      verbosef,
+	}
+
+	// processText turns content into the text shown for a file, up to and
+	// including the -match check, and reports false if -match finds nothing.
+	// With report unset nothing is logged, for the -jobs prepass.
+	processText := func(filePath string, content []byte, report bool) (string, bool) {
+		warnf := func(format string, args ...any) {
+			if report && !opts.Quiet {
+				log.Printf(format, args...)
+			}
+		}
+		text, replaced := decodeText(content)
+		if replaced {
+			warnf("Warning: %s is not valid UTF-8; invalid bytes were replaced", filePath)
+		}
+		if opts.NormalizeEOL {
+			text = normalizeLineEndings(text)
+		}
+		if opts.TrimBlankLines {
+			text = trimBlankLines(text)
+		}
+		if opts.SignaturesOnly {
+			if language := detectLanguage(filePath, text, opts.DetectContent); language != "go" {
+				if report {
+					verbosef("Keeping all of %s: -signatures-only only supports Go", filePath)
+				}
+			} else if signatures, err := goSignatures(text); err != nil {
+				warnf("Warning: keeping all of %s because it could not be parsed: %v", filePath, err)
+			} else {
+				text = signatures
+			}
+		}
+		if opts.SquashImports {
+			text = squashImports(text, detectLanguage(filePath, text, opts.DetectContent))
+		}
+		if matchRegex != nil {
+			windows, found := matchWindows(text, matchRegex, opts.Before, opts.After)
+			if !found {
+				if report {
+					verbosef("Skipping %s: no line matches -match", filePath)
+				}
+				return "", false
+			}
+			text = windows
+		}
+		return text, true
+	}
+
+	// runFileExec runs a per-file executable. In replace mode it returns the
+	// stdout that replaces the content, otherwise the labelled output.
+	runFileExec := func(ctx context.Context, job execJob) (string, error) {
+		runSettings := settings
+		if opts.ExecMode == ExecModeReplace {
+			// The executable transforms the content on stdin, and only its
+			// stdout is the new content; stderr would corrupt it
+			content, err := os.ReadFile(job.Path)
+			if err != nil {
+				return "", fmt.Errorf("failed to read %s for -exec-mode replace: %v", job.Path, err)
+			}
+			runSettings.Stdin = append([]byte{}, content...)
+			runSettings.IncludeStderr = false
+		}
+		output, err := runExecutable(ctx, job.Executable, []string{job.Path}, runSettings)
+		if err != nil {
+			return "", withExecOrigin(err, execOrigin(job.Executable, opts.ExecCommand, finalFileTypeExecutables))
+		}
+		if opts.ExecMode == ExecModeReplace {
+			return output, nil
+		}
+		return labelExecOutput(execLabelTemplate, output, execLabel{Command: job.Executable, Path: job.Path})
+	}
+
+	// Reuse files processed by an earlier run while they are unchanged
+	cache := newFileCache(opts, finalFileTypeExecutables, redactPatterns, secretFiles, generatedPatterns)
+
+	// With -jobs, run the per-file executables concurrently before writing
+	// anything; their output is still placed in file order below. Files the
+	// loop below leaves out before running their executable are left out here
+	// too, so executables only run on files that are extracted.
+	var execOutputs map[string]string
+	if opts.Jobs > 1 && opts.ExecMode != ExecModeBatch {
+		var jobs []execJob
+		seen := make(map[string]bool) // Content hashes, for -dedupe-content
+		for _, group := range groups {
+			for _, source := range group.Files {
+				if source.InMemory || (manifestRegex != nil && manifestRegex.MatchString(source.Path)) {
+					continue
+				}
+				if cache != nil && !source.NoCache {
+					if entry, ok := cache.get(cache.key(source.Path)); ok {
+						seen[entry.ContentHash] = true
+						continue
+					}
+				}
+				executable := executableFor(source.Path)
+				if executable == "" && !opts.DedupeContent {
+					continue
+				}
+				content, err := os.ReadFile(source.Path)
+				if err != nil {
+					continue // Reported when the file is written
+				}
+				if skipContent(source.Path, content, false) {
+					continue
+				}
+				if opts.ExecMode != ExecModeReplace {
+					if _, keep := processText(source.Path, content, false); !keep {
+						continue
+					}
+				}
+				if opts.DedupeContent {
+					hash := contentHash(content)
+					if seen[hash] {
+						continue
+					}
+					seen[hash] = true
+				}
+				if executable != "" {
+					jobs = append(jobs, execJob{Executable: executable, Path: source.Path})
+				}
+			}
+		}
+		outputs, err := runExecJobs(ctx, jobs, opts.Jobs, runFileExec)
+		if err != nil {
+			return err
+		}
+		execOutputs = make(map[string]string, len(jobs))
+		for i, job := range jobs {
+			execOutputs[job.Path] = outputs[i]
+		}
+	}
+
+	// Executables to run once over all of their files in batch mode, in first-use order
+	batches := make(map[string][]string)
+	var batchOrder []string
+
+	// Files whose executable output is written after all files
+	var separateOutputs []fileSection
+
+	// Totals for the -summary footer, counting only files that were written
+	var extractedFiles, extractedLines int
+
+	// queueBatch defers a batch mode executable to a single run over all files sharing it
+	queueBatch := func(executable, filePath string) {
+		if executable == "" || opts.ExecMode != ExecModeBatch {
+			return
+		}
+		if _, exists := batches[executable]; !exists {
+			batchOrder = append(batchOrder, executable)
+		}
+		batches[executable] = append(batches[executable], filePath)
+	}
+
+	// First file seen with each content hash for -dedupe-content
+	firstWithContent := make(map[string]string)
+
+	// noteDuplicate writes a note in place of a file whose content matches an
+	// earlier file and reports whether it did. An empty hash is never a duplicate.
+	noteDuplicate := func(source sourceFile, hash string) bool {
+		if hash == "" {
+			return false
+		}
+		first, seen := firstWithContent[hash]
+		if !seen {
+			firstWithContent[hash] = headerPath(source, opts)
+			return false
+		}
+		verbosef("Skipping %s: identical to %s", source.Path, first)
+		output.WriteString(fmt.Sprintf("%s (identical to %s)\n", headerPath(source, opts), first))
+		output.WriteDelimiter(delimiter(""))
+		data.Files = append(data.Files, documentFile{Path: headerPath(source, opts), Language: languageFor(source.Path), IdenticalTo: first})
+		extractedFiles++
+		return true
+	}
+
+	// writeFile writes a processed file under its header and counts it for
+	// -summary and -template-file
+	writeFile := func(source sourceFile, entry cachedFile) error {
+		header := headerPath(source, opts)
+		if opts.Metadata {
+			if metadata := fileMetadata(source); metadata != "" {
+				header += " (" + metadata + ")"
+			}
+		}
+		if opts.Perms {
+			if perms := filePerms(source); perms != "" {
+				header += " [" + perms + "]"
+			}
+		}
+		body := entry.Text + "\n"
+		if opts.WrapCode {
+			fence := codeFence(entry.Text, fenceChar(opts.FenceStyle), opts.FenceLen)
+			info, err := renderFenceInfo(fenceInfoTemplate, fenceInfo{Path: source.Path, Language: entry.Language})
+			if err != nil {
+				return err
+			}
+			body = fence + info + "\n" + body + fence + "\n"
+		}
+		section := fileSection{Header: header, Body: body, ExecOutput: entry.ExecOutput}
+		writeFileSection(output, section, opts.ExecOutputPosition, delimiter(entry.Language))
+		if section.ExecOutput != "" && opts.ExecOutputPosition == ExecOutputSeparate {
+			separateOutputs = append(separateOutputs, section)
+		}
+		data.Files = append(data.Files, documentFile{Path: headerPath(source, opts), Language: entry.Language, Content: entry.Text, ExecOutput: section.ExecOutput})
+		extractedFiles++
+		extractedLines += countLines(entry.Text)
+		return nil
+	}
+
+	// Process each file
+	for _, group := range groups {
+		if opts.GroupByLanguage {
+			output.WriteString(group.Language + " files\n")
+			output.WriteDelimiter(delimiter(""))
+		}
+		for _, source := range group.Files {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			filePath := source.Path
+
+			// List manifest files by header and size only, without reading or running them
+			if manifestRegex != nil && manifestRegex.MatchString(filePath) {
+				size := fileSize(source)
+				output.WriteString(fmt.Sprintf("%s (contents omitted, %d bytes)\n", headerPath(source, opts), size))
+				output.WriteDelimiter(delimiter(languageFor(filePath)))
+				data.Files = append(data.Files, documentFile{Path: headerPath(source, opts), Language: languageFor(filePath), Omitted: true, Size: size})
+				extractedFiles++
+				continue
+			}
+
+			// In-memory files have no path on disk to pass to an executable
+			executable := ""
+			if !source.InMemory {
+				executable = executableFor(filePath)
+			}
+
+			// Use the file as processed by an earlier run if it has not changed since
+			var cacheKey string
+			if cache != nil && !source.InMemory && !source.NoCache {
+				cacheKey = cache.key(filePath)
+				if entry, ok := cache.get(cacheKey); ok {
+					verbosef("Using cached %s", filePath)
+					if noteDuplicate(source, entry.ContentHash) {
+						continue
+					}
+					queueBatch(executable, filePath)
+					if err := writeFile(source, entry); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+
+			// Read file content
+			content := source.Content
+			if !source.InMemory {
+				var err error
+				content, err = os.ReadFile(filePath)
+				if err != nil {
+					fileErrs = append(fileErrs, fileError{Path: filePath, Err: err})
+					continue
+				}
+			}
+
+			if skipContent(filePath, content, true) {
+				continue
+			}
+
+			// Unless the executable replaces the content, the text does not
+			// depend on it, so -match can skip the file before it runs
+			var text string
+			if opts.ExecMode != ExecModeReplace {
+				var keep bool
+				if text, keep = processText(filePath, content, true); !keep {
+					continue
+				}
+			}
+
+			// Note files identical to an earlier one instead of repeating them
+			var hash string
+			if opts.DedupeContent {
+				hash = contentHash(content)
+				if noteDuplicate(source, hash) {
+					continue
+				}
+			}
+
+			// Run the executable if one is specified. In replace mode its
+			// output becomes the content.
+			var executableOutput string
+			if executable != "" && opts.ExecMode == ExecModeBatch {
+				queueBatch(executable, filePath)
+			} else if executable != "" {
+				// Use the output of an earlier run on the -jobs workers if there was one
+				fileOutput, ok := execOutputs[filePath]
+				if !ok {
+					var err error
+					fileOutput, err = runFileExec(ctx, execJob{Executable: executable, Path: filePath})
+					if err != nil {
+						return err
+					}
+				}
+				if opts.ExecMode == ExecModeReplace {
+					content = []byte(fileOutput)
+				} else {
+					executableOutput = fileOutput
+				}
+			}
+			if opts.ExecMode == ExecModeReplace {
+				var keep bool
+				if text, keep = processText(filePath, content, true); !keep {
+					continue
+				}
+			}
+
+			if opts.TruncateFileBytes > 0 && len(text) > opts.TruncateFileBytes {
+				verbosef("Truncating %s: %d bytes is over -truncate-file-bytes", filePath, len(text))
+				text = truncateMiddle(text, opts.TruncateFileBytes, opts.TruncateHeadRatio)
+			}
+			if secrets != nil {
+				var redactions int
+				text, redactions = secrets.Redact(text)
+				if redactions > 0 && !opts.Quiet {
+					log.Printf("Redacted %d secret(s) in %s", redactions, filePath)
+				}
+			}
+
+			entry := cachedFile{
+				// Detect language based on file extension, or content with -detect-content
+				Language:    detectLanguage(filePath, text, opts.DetectContent),
+				Text:        text,
+				ExecOutput:  executableOutput,
+				ContentHash: hash,
+			}
+			if cache != nil {
+				if err := cache.put(cacheKey, entry); err != nil && !opts.Quiet {
+					log.Printf("Warning: %v", err)
+				}
+			}
+			if err := writeFile(source, entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Keep the cache within its size limit
+	if cache != nil {
+		if err := cache.prune(CacheMaxBytes); err != nil && !opts.Quiet {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	// Collect per-file executable output after all files for -exec-output-position separate
+	if len(separateOutputs) > 0 {
+		output.WriteString("Executable output\n")
+		output.WriteDelimiter(delimiter(""))
+		for _, section := range separateOutputs {
+			output.WriteString(section.Header + "\n")
+			output.WriteString(section.ExecOutput + "\n")
+			output.WriteDelimiter(delimiter(""))
+		}
+	}
+
+	// Add a unified diff for every -diff pair
+	for i := 0; i+1 < len(opts.Diff); i += 2 {
+		oldPath, newPath := opts.Diff[i], opts.Diff[i+1]
+		diff, err := fileDiff(oldPath, newPath)
+		if err != nil {
+			fileErrs = append(fileErrs, fileError{Path: oldPath + " " + newPath, Err: err})
+			continue
+		}
+		if diff == "" {
+			diff = "(no differences)\n"
+		}
+		data.Diffs = append(data.Diffs, documentDiff{Old: oldPath, New: newPath, Diff: diff})
+		output.WriteString(fmt.Sprintf("Diff of %s and %s\n", oldPath, newPath))
+		if opts.WrapCode {
+			fence := codeFence(diff, fenceChar(opts.FenceStyle), opts.FenceLen)
+			output.WriteString(fence + "diff\n" + diff + fence + "\n")
+		} else {
+			output.WriteString(diff)
+		}
+		output.WriteDelimiter(delimiter(""))
+	}
+
+	// Report files that could not be read together rather than one at a time
+	if err := checkFileErrors(fileErrs, opts); err != nil {
+		return err
+	}
+
+	// Run batched executables and place their output after all files
+	for _, executable := range batchOrder {
+		executableOutput, err := runExecutable(ctx, executable, batches[executable], settings)
+		if err != nil {
+			return withExecOrigin(err, execOrigin(executable, opts.ExecCommand, finalFileTypeExecutables))
+		}
+		executableOutput, err = labelExecOutput(execLabelTemplate, executableOutput, execLabel{Command: executable, Path: strings.Join(batches[executable], " ")})
+		if err != nil {
+			return err
+		}
+		output.WriteString(executableOutput + "\n")
+		output.WriteDelimiter(delimiter(""))
+		data.Batches = append(data.Batches, documentBatch{Command: executable, Paths: batches[executable], Output: executableOutput})
+	}
+
+	if renderLayout != nil {
+		// Lay out the whole document, including the -prepend and -append text
+		data.Delimiter = opts.Delimiter
+		if prefix != "" {
+			data.Prepend = withTrailingNewline(prefix)
+		}
+		if suffix != "" {
+			data.Append = withTrailingNewline(suffix)
+		}
+		document, err := renderLayout(data)
+		if err != nil {
+			return err
+		}
+		if opts.Summary && opts.Format == FormatXML {
+			tokens := estimateTokens(len(document))
+			document = insertXMLSummary(document, formatSummary(extractedFiles, extractedLines, tokens))
+		}
+		output = &outputWriter{w: target}
+		output.WriteString(document)
+	} else {
+		// Drop the delimiter after the last section, wherever executable output was placed
+		output.EndSections(opts.NoTrailingDelimiter)
+		if suffix != "" {
+			output.WriteString(withTrailingNewline(suffix))
+		}
+	}
+	if opts.Summary && opts.Format != FormatXML {
+		tokens := estimateTokens(output.Written())
+		output.WriteString(formatSummary(extractedFiles, extractedLines, tokens) + "\n")
+	}
+	if err := output.Err(); err != nil {
+		return fmt.Errorf("failed to write output: %v", err)
+	}
+
+	// Pipe the assembled output through the -post-exec command
+	if opts.PostExec != "" {
+		processed, err := runPostExec(ctx, opts.PostExec, unprocessed.String(), settings)
+		if err != nil {
+			if opts.Strict || ctx.Err() != nil {
+				return err
+			}
+			if !opts.Quiet {
+				log.Printf("Warning: using the output without -post-exec: %v", err)
+			}
+			processed = unprocessed.String()
+		}
+		if _, err := io.WriteString(w, processed); err != nil {
+			return fmt.Errorf("failed to write output: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,573 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFiles creates the named files with their contents under dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// parseIn returns an App without a config file and the Options parsed from
+// args as the command line would, with header paths relative to dir.
+func parseIn(t *testing.T, dir string, args ...string) (*App, Options) {
+	t.Helper()
+	app, err := NewApp(filepath.Join(t.TempDir(), "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := ParseArguments(append([]string{"-base-dir", dir}, args...), app.DefaultDelimiter(), app.Defaults())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return app, *opts
+}
+
+// extractIn returns the output of extracting args with header paths relative to dir.
+func extractIn(t *testing.T, dir string, args ...string) (string, error) {
+	t.Helper()
+	app, opts := parseIn(t, dir, args...)
+	return app.Extract(opts)
+}
+
+func TestExtract(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go":  "package a\n",
+		"b.txt": "hello",
+	})
+	a, b := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.txt")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "defaults",
+			args: []string{"-files", a, b},
+			want: "a.go\n```go\npackage a\n\n```\n======\nb.txt\n```plaintext\nhello\n```\n======\n",
+		},
+		{
+			name: "no code fences",
+			args: []string{"-files", a, "-wrap-code", "false"},
+			want: "a.go\npackage a\n\n======\n",
+		},
+		{
+			name: "custom delimiter",
+			args: []string{"-files", a, b, "-delimiter", "----"},
+			want: "a.go\n```go\npackage a\n\n```\n----\nb.txt\n```plaintext\nhello\n```\n----\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractIn(t, dir, tt.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupeFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.go": "", "b.go": ""})
+	a, b := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")
+	link := filepath.Join(dir, "link.go")
+	if err := os.Symlink(a, link); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{"no duplicates", []string{b, a}, []string{b, a}},
+		{"repeated path", []string{a, b, a}, []string{a, b}},
+		{"unclean path", []string{a, filepath.Join(dir, ".", "a.go")}, []string{a}},
+		{"symlink to an earlier file", []string{a, link}, []string{a}},
+		{"symlink first", []string{link, b, a}, []string{link, b}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dedupeFiles(tt.files); !slices.Equal(got, tt.want) {
+				t.Errorf("dedupeFiles(%q) = %q, want %q", tt.files, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractOverlappingGlobs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.go": "package a\n", "b.go": "package b\n"})
+	a, b := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")
+
+	// The shell expands "a* *.go" and a directory expands to its files
+	for _, files := range [][]string{{a, a, b}, {a, dir}} {
+		got, err := extractIn(t, dir, append([]string{"-files"}, files...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := strings.Count(got, "package a"); n != 1 {
+			t.Errorf("-files %q includes a.go %d times, want once:\n%s", files, n, got)
+		}
+		if n := strings.Count(got, "package b"); n != 1 {
+			t.Errorf("-files %q includes b.go %d times, want once:\n%s", files, n, got)
+		}
+		if strings.Index(got, "package a") > strings.Index(got, "package b") {
+			t.Errorf("-files %q does not keep a.go first:\n%s", files, got)
+		}
+	}
+}
+
+func TestExtractContextCancel(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.go": "package a\n", "b.go": "package b\n"})
+	files := []string{"-files", filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")}
+
+	t.Run("before extraction", func(t *testing.T) {
+		app, opts := parseIn(t, dir, files...)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := app.ExtractContext(ctx, opts); !errors.Is(err, context.Canceled) {
+			t.Errorf("ExtractContext error = %v, want %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("during an executable", func(t *testing.T) {
+		app, opts := parseIn(t, dir, append(files, "-exec", `sh -c "sleep 10; echo done"`)...)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err := app.ExtractContext(ctx, opts)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("ExtractContext error = %v, want %v", err, context.Canceled)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("ExtractContext returned after %s, want it to stop when cancelled", elapsed)
+		}
+	})
+}
+
+func TestExtractManifestPattern(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go":           "package a\n",
+		"data/rows.csv":  "a,b\n1,2\n",
+		"data/empty.csv": "",
+	})
+	files := []string{"-files", filepath.Join(dir, "a.go"), filepath.Join(dir, "data")}
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    string
+	}{
+		{
+			name:    "csv files",
+			pattern: `\.csv$`,
+			want: "a.go\n```go\npackage a\n\n```\n======\n" +
+				"data/empty.csv (contents omitted, 0 bytes)\n======\n" +
+				"data/rows.csv (contents omitted, 8 bytes)\n======\n",
+		},
+		{
+			name:    "no match",
+			pattern: `\.json$`,
+			want: "a.go\n```go\npackage a\n\n```\n======\n" +
+				"data/empty.csv\n```plaintext\n\n```\n======\n" +
+				"data/rows.csv\n```plaintext\na,b\n1,2\n\n```\n======\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractIn(t, dir, append(files, "-manifest-pattern", tt.pattern)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractExecOutputPosition(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.go": "package a\n", "b.go": "package b\n"})
+	files := []string{"-files", filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")}
+
+	tests := []struct {
+		position string
+		want     string
+	}{
+		{
+			position: ExecOutputAfter,
+			want: "a.go\n```go\npackage a\n\n```\nout\n\n======\n" +
+				"b.go\n```go\npackage b\n\n```\nout\n\n======\n",
+		},
+		{
+			position: ExecOutputBefore,
+			want: "a.go\nout\n\n```go\npackage a\n\n```\n======\n" +
+				"b.go\nout\n\n```go\npackage b\n\n```\n======\n",
+		},
+		{
+			position: ExecOutputSeparate,
+			want: "a.go\n```go\npackage a\n\n```\n======\n" +
+				"b.go\n```go\npackage b\n\n```\n======\n" +
+				"Executable output\n======\n" +
+				"a.go\nout\n\n======\n" +
+				"b.go\nout\n\n======\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.position, func(t *testing.T) {
+			args := append(files, "-exec", `sh -c "echo out"`, "-exec-label-template", "", "-exec-output-position", tt.position)
+			got, err := extractIn(t, dir, args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractExecLabel(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.go": "package a\n", "b.go": "package b\n"})
+	files := []string{"-files", filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")}
+
+	for _, mode := range []string{ExecModePerFile, ExecModeBatch} {
+		t.Run(mode, func(t *testing.T) {
+			got, err := extractIn(t, dir, append(files, "-exec", `sh -c "echo out"`, "-exec-mode", mode)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(got, "--- output of `sh -c \"echo out\"` ---\nout\n") {
+				t.Errorf("output has no label before the executable output:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestExtractMissingExecutable(t *testing.T) {
+	const missing = "go-file-extract-missing-tool"
+	if _, err := exec.LookPath(missing); err == nil {
+		t.Skipf("%s is installed", missing)
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.go": "package a\n"})
+	path := filepath.Join(dir, "a.go")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"exec", []string{"-exec", missing}, "executable '" + missing + "' not found on PATH; passed with -exec"},
+		{"file-exec", []string{"-file-exec", ".go=" + missing}, "executable '" + missing + "' not found on PATH; configured for .go files"},
+		{"batch", []string{"-exec", missing, "-exec-mode", ExecModeBatch}, "executable '" + missing + "' not found on PATH; passed with -exec"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := extractIn(t, dir, append([]string{"-files", path}, tt.args...)...)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+			var execErr *ExecError
+			if !errors.As(err, &execErr) {
+				t.Errorf("error %v is not an *ExecError", err)
+			}
+		})
+	}
+}
+
+func TestExtractMinified(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"app.min.js": strings.Repeat("var a=1;", 1000),
+		"app.js":     "var a = 1;\n",
+	})
+	files := []string{"-files", filepath.Join(dir, "app.min.js"), filepath.Join(dir, "app.js"), "-quiet"}
+
+	tests := []struct {
+		name         string
+		args         []string
+		needs        string // Program the case runs, if any
+		wantMinified bool
+	}{
+		{"skipped", nil, "", false},
+		{"included", []string{"-include-minified"}, "", true},
+		// The check uses the content as read, before an executable reformats it
+		{"skipped before exec", []string{"-exec-mode", ExecModeReplace, "-exec", `tr ";" "\n"`}, "tr", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.needs != "" {
+				if _, err := exec.LookPath(tt.needs); err != nil {
+					t.Skipf("%s is not available", tt.needs)
+				}
+			}
+			got, err := extractIn(t, dir, append(files, tt.args...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if minified := strings.Contains(got, "app.min.js"); minified != tt.wantMinified {
+				t.Errorf("output includes app.min.js = %t, want %t:\n%s", minified, tt.wantMinified, got)
+			}
+			if !strings.Contains(got, "app.js\n") {
+				t.Errorf("output is missing app.js:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestExtractCount(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"app.min.js": strings.Repeat("var a=1;", 1000),
+		"app.js":     "var a = 1;\n",
+		"gen.go":     "// Code generated by x. DO NOT EDIT.\npackage g\n",
+		"a.go":       "package a\n",
+		"copy.go":    "package a\n",
+	})
+	var files []string
+	for _, name := range []string{"app.min.js", "app.js", "gen.go", "a.go", "copy.go"} {
+		files = append(files, filepath.Join(dir, name))
+	}
+	files = append([]string{"-count", "-quiet", "-files"}, files...)
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			// The minified file is left out as it is from the extraction
+			name: "defaults",
+			want: "LANGUAGE    FILES  BYTES  LINES  TOKENS\n" +
+				"go          3      67     4      ~17\n" +
+				"javascript  1      11     1      ~3\n" +
+				"Total       4      78     5      ~20\n",
+		},
+		{
+			name: "include minified",
+			args: []string{"-include-minified"},
+			want: "LANGUAGE    FILES  BYTES  LINES  TOKENS\n" +
+				"go          3      67     4      ~17\n" +
+				"javascript  2      8,011  2      ~2,003\n" +
+				"Total       5      8,078  6      ~2,020\n",
+		},
+		{
+			name: "skip generated and dedupe content",
+			args: []string{"-skip-generated", "-dedupe-content"},
+			want: "LANGUAGE    FILES  BYTES  LINES  TOKENS\n" +
+				"go          2      10     1      ~3\n" +
+				"javascript  1      11     1      ~3\n" +
+				"Total       3      21     2      ~6\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractIn(t, dir, append(tt.args, files...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("output =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractDelimiterStyle(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.go": "package a\n", "b.py": "pass\n"})
+	files := []string{"-files", filepath.Join(dir, "a.go"), filepath.Join(dir, "b.py"), "-wrap-code", "false"}
+
+	tests := []struct {
+		style string
+		want  string
+	}{
+		{DelimiterStyleRaw, "a.go\npackage a\n\n======\nb.py\npass\n\n======\n"},
+		{DelimiterStyleComment, "a.go\npackage a\n\n// ======\nb.py\npass\n\n# ======\n"},
+		{DelimiterStyleMarkdown, "a.go\npackage a\n\n\n---\nb.py\npass\n\n\n---\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			got, err := extractIn(t, dir, append(files, "-delimiter-style", tt.style)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractNoTrailingDelimiter(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.go": "package a\n", "b.go": "package b\n"})
+	files := []string{"-files", filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go"), "-no-trailing-delimiter", "-wrap-code", "false"}
+	execArgs := []string{"-exec", `sh -c "echo out"`, "-exec-label-template", ""}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"files only", nil, "a.go\npackage a\n\n======\nb.go\npackage b\n\n"},
+		{
+			name: "exec output after",
+			args: slices.Concat(execArgs, []string{"-exec-output-position", ExecOutputAfter}),
+			want: "a.go\npackage a\n\nout\n\n======\nb.go\npackage b\n\nout\n\n",
+		},
+		{
+			name: "exec output separate",
+			args: slices.Concat(execArgs, []string{"-exec-output-position", ExecOutputSeparate}),
+			want: "a.go\npackage a\n\n======\nb.go\npackage b\n\n======\n" +
+				"Executable output\n======\na.go\nout\n\n======\nb.go\nout\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractIn(t, dir, append(files, tt.args...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractSignaturesOnly(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go":      "package a\n\nfunc F() int {\n\treturn 1\n}\n",
+		"b.py":      "def f():\n    return 1\n",
+		"broken.go": "package a\n\nfunc F( {\n",
+	})
+	args := []string{"-files", filepath.Join(dir, "a.go"), filepath.Join(dir, "b.py"), filepath.Join(dir, "broken.go"), "-signatures-only", "-wrap-code", "false", "-quiet"}
+
+	got, err := extractIn(t, dir, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a.go\npackage a\n\nfunc F() int\n\n======\n" +
+		"b.py\ndef f():\n    return 1\n\n======\n" +
+		"broken.go\npackage a\n\nfunc F( {\n\n======\n"
+	if got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestExtractDedupeContent(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go":     "package a\n",
+		"b.txt":    "hello\n",
+		"copy.go":  "package a\n",
+		"other.go": "package a\n\n",
+	})
+	var files []string
+	for _, name := range []string{"a.go", "b.txt", "copy.go", "other.go"} {
+		files = append(files, filepath.Join(dir, name))
+	}
+	files = append([]string{"-files"}, files...)
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "off",
+			want: "a.go\n```go\npackage a\n\n```\n======\nb.txt\n```plaintext\nhello\n\n```\n======\n" +
+				"copy.go\n```go\npackage a\n\n```\n======\nother.go\n```go\npackage a\n\n\n```\n======\n",
+		},
+		{
+			name: "on",
+			args: []string{"-dedupe-content"},
+			want: "a.go\n```go\npackage a\n\n```\n======\nb.txt\n```plaintext\nhello\n\n```\n======\n" +
+				"copy.go (identical to a.go)\n======\nother.go\n```go\npackage a\n\n\n```\n======\n",
+		},
+		{
+			// Executables queued by -jobs skip the duplicate too
+			name: "with jobs",
+			args: []string{"-dedupe-content", "-jobs", "4", "-exec", `sh -c "echo out"`, "-exec-label-template", ""},
+			want: "a.go\n```go\npackage a\n\n```\nout\n\n======\nb.txt\n```plaintext\nhello\n\n```\nout\n\n======\n" +
+				"copy.go (identical to a.go)\n======\nother.go\n```go\npackage a\n\n\n```\nout\n\n======\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractIn(t, dir, append(files, tt.args...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractExecModeReplace(t *testing.T) {
+	if _, err := exec.LookPath("tr"); err != nil {
+		t.Skip("tr is not available")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.go": "package a\n"})
+
+	got, err := extractIn(t, dir, "-files", filepath.Join(dir, "a.go"), "-exec-mode", ExecModeReplace, "-exec", "tr a-z A-Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a.go\n```go\nPACKAGE A\n\n```\n======\n"
+	if got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+package extract
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// DefaultFenceInfoTemplate puts just the language after the opening fence.
+const DefaultFenceInfoTemplate = "{{.Language}}"
+
+// minFenceLen is the shortest code fence CommonMark allows.
+const minFenceLen = 3
+
+// Values accepted by -fence-style.
+const (
+	FenceStyleBacktick = "backtick" // Fence code with ```
+	FenceStyleTilde    = "tilde"    // Fence code with ~~~
+)
+
+// fenceChar returns the character used for fences of the given style.
+func fenceChar(style string) byte {
+	if style == FenceStyleTilde {
+		return '~'
+	}
+	return '`'
+}
+
+// fenceInfo is the data available to -fence-info-template.
+type fenceInfo struct {
+	Path     string
+	Language string
+}
+
+// parseFenceInfoTemplate compiles a -fence-info-template value and renders it
+// once so unknown fields are reported before any file is read.
+func parseFenceInfoTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("fence-info").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -fence-info-template: %v", err)
+	}
+	if err := tmpl.Execute(io.Discard, fenceInfo{Path: "main.go", Language: "go"}); err != nil {
+		return nil, fmt.Errorf("invalid -fence-info-template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// renderFenceInfo returns the info string written after the opening fence.
+// Newlines are removed because the info string must stay on the fence line.
+func renderFenceInfo(tmpl *template.Template, info fenceInfo) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, info); err != nil {
+		return "", fmt.Errorf("failed to render -fence-info-template for %s: %v", info.Path, err)
+	}
+	return strings.ReplaceAll(b.String(), "\n", " "), nil
+}
+
+// codeFence returns a fence of fenceChar long enough to wrap content: at least
+// minLen characters and longer than any run of fenceChar inside content, so
+// fences in the content cannot close it early.
+func codeFence(content string, fenceChar byte, minLen int) string {
+	longest, run := 0, 0
+	for i := 0; i < len(content); i++ {
+		if content[i] == fenceChar {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat(string(fenceChar), max(minLen, minFenceLen, longest+1))
+}
@@ -0,0 +1,124 @@
+package extract
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCodeFence(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		fenceChar byte
+		minLen    int
+		want      string
+	}{
+		{"plain", "package a\n", '`', 0, "```"},
+		{"inline code", "use `x` here\n", '`', 0, "```"},
+		{"nested fence", "```go\nx\n```\n", '`', 0, "````"},
+		{"longer nested fence", "`````\nx\n`````\n", '`', 0, "``````"},
+		{"min length", "x\n", '`', 5, "`````"},
+		{"min length below nested fence", "````\n", '`', 4, "`````"},
+		{"min length below three", "x\n", '`', 1, "```"},
+		{"tilde ignores backticks", "```\nx\n```\n", '~', 0, "~~~"},
+		{"tilde nested fence", "~~~~\nx\n~~~~\n", '~', 0, "~~~~~"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := codeFence(tt.content, tt.fenceChar, tt.minLen); got != tt.want {
+				t.Errorf("codeFence(%q, %q, %d) = %q, want %q", tt.content, tt.fenceChar, tt.minLen, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractMarkdownWithCodeBlock(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"README.md": "# Usage\n```sh\nmake\n```\n"})
+
+	got, err := extractIn(t, dir, "-files", filepath.Join(dir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "README.md\n````markdown\n# Usage\n```sh\nmake\n```\n\n````\n======\n"
+	if got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestExtractFenceStyles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"run.sh": "echo `date`\n"})
+	path := filepath.Join(dir, "run.sh")
+
+	backtick, err := extractIn(t, dir, "-files", path, "-fence-style", FenceStyleBacktick)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tilde, err := extractIn(t, dir, "-files", path, "-fence-style", FenceStyleTilde)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "run.sh\n~~~bash\necho `date`\n\n~~~\n======\n"
+	if tilde != want {
+		t.Errorf("tilde output = %q, want %q", tilde, want)
+	}
+	if got := strings.ReplaceAll(tilde, "~~~", "```"); got != backtick {
+		t.Errorf("tilde output %q differs from backtick output %q beyond the fence", tilde, backtick)
+	}
+}
+
+func TestFenceInfoTemplate(t *testing.T) {
+	info := fenceInfo{Path: "cmd/main.go", Language: "go"}
+	tests := []struct {
+		name     string
+		template string
+		info     fenceInfo
+		want     string
+		wantErr  bool
+	}{
+		{"default", DefaultFenceInfoTemplate, info, "go", false},
+		{"title", "{{.Language}} title={{.Path}}", info, "go title=cmd/main.go", false},
+		{"empty", "", info, "", false},
+		{"newline in template", "{{.Language}}\n{{.Path}}\n", info, "go cmd/main.go ", false},
+		{"newline in path", "{{.Language}} title={{.Path}}", fenceInfo{Path: "a\nb.go", Language: "go"}, "go title=a b.go", false},
+		{"unknown field", "{{.Size}}", info, "", true},
+		{"syntax error", "{{.Language", info, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseFenceInfoTemplate(tt.template)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseFenceInfoTemplate(%q) error = nil, want an error", tt.template)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := renderFenceInfo(tmpl, tt.info)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("renderFenceInfo(%q, %+v) = %q, want %q", tt.template, tt.info, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractFenceInfoTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.go": "package a\n"})
+	got, err := extractIn(t, dir, "-files", filepath.Join(dir, "a.go"), "-fence-info-template", "{{.Language}} title={{.Path}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a.go\n```go title=" + filepath.Join(dir, "a.go") + "\npackage a\n\n```\n======\n"
+	if got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,516 @@
+package extract
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// usageHeader introduces the flag list printed by -help.
+const usageHeader = `Usage: go-file-extract [flags]
+
+Extracts files into a single prompt-friendly document and copies it to the clipboard.
+Run without flags to pick a saved configuration for the current folder.
+
+Flags:
+`
+
+// Options holds the parsed command-line arguments.
+type Options struct {
+	Files                []string
+	IgnorePattern        string
+	IncludePatterns      []string
+	IgnoreGitIgnore      bool
+	Delimiter            string
+	WrapCode             bool
+	SaveName             string
+	SaveGlobalName       string
+	ByName               []string
+	ExecCommand          string
+	FileExecs            map[string]string
+	TrimBlankLines       bool
+	NormalizeEOL         bool
+	Prepend              string
+	Append               string
+	Tree                 bool
+	ExecTimeout          time.Duration
+	ExecMaxOutput        int
+	ExecStderr           bool
+	ExecMode             string
+	Sort                 string
+	GroupByLanguage      bool
+	Summary              bool
+	Compress             bool
+	Decompress           bool
+	Metadata             bool
+	Redact               bool
+	IncludeSecrets       bool
+	FenceInfoTemplate    string
+	FenceLen             int
+	FenceStyle           string
+	Relative             bool
+	BaseDir              string
+	ExcludeDirs          []string
+	MaxDepth             int
+	FollowSymlinks       bool
+	ClipboardSelection   string
+	ClipboardCmd         string
+	Hidden               bool
+	Strict               bool
+	ManifestPattern      string
+	Match                string
+	Before               int
+	After                int
+	Preset               string
+	Count                bool
+	NoBackup             bool
+	Completion           string
+	ListSaved            bool
+	PrintConfig          bool
+	ExecOutputPosition   string
+	ExecLabelTemplate    string
+	ExecRetries          int
+	Init                 bool
+	Force                bool
+	Diff                 []string
+	DetectContent        bool
+	IncludeMinified      bool
+	AllowRemote          bool
+	Timeout              time.Duration
+	PostExec             string
+	ChunkBytes           int
+	ChunkPrefix          string
+	TOC                  bool
+	TruncateFileBytes    int
+	TruncateHeadRatio    float64
+	ForceIncludePatterns []string
+	Perms                bool
+	ExportConfig         string
+	ImportConfig         string
+	DelimiterStyle       string
+	NoTrailingDelimiter  bool
+	RelativeToGitRoot    bool
+	TemplateFile         string
+	Jobs                 int
+	Cache                bool
+	NoCache              bool
+	ClearCache           bool
+	RespectGitAttributes bool
+	Format               string
+	LineNumbers          bool
+	SkipGenerated        bool
+	SquashImports        bool
+	SignaturesOnly       bool
+	DedupeContent        bool
+	VerifyClipboard      bool
+	ConfigPath           string
+	NoClipboard          bool
+	Quiet                bool
+	Verbose              bool
+	Help                 bool
+	Version              bool
+}
+
+// stringsValue is a repeatable flag that collects every value passed to it.
+type stringsValue []string
+
+func (v *stringsValue) String() string { return strings.Join(*v, " ") }
+
+func (v *stringsValue) Set(value string) error {
+	*v = append(*v, value)
+	return nil
+}
+
+// namesValue is a repeatable flag whose values may also be comma-separated lists.
+type namesValue []string
+
+func (v *namesValue) String() string { return strings.Join(*v, ",") }
+
+func (v *namesValue) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			*v = append(*v, name)
+		}
+	}
+	return nil
+}
+
+// filesValue collects -files values; on the command line -files takes every
+// following argument up to the next flag.
+type filesValue []string
+
+func (v *filesValue) String() string { return strings.Join(*v, " ") }
+
+func (v *filesValue) Set(value string) error {
+	*v = append(*v, value)
+	return nil
+}
+
+// fileExecsValue parses space-separated .ext=executable pairs into a map.
+type fileExecsValue map[string]string
+
+func (v fileExecsValue) String() string {
+	var pairs []string
+	for ext, cmd := range v {
+		pairs = append(pairs, ext+"="+cmd)
+	}
+	return strings.Join(pairs, " ")
+}
+
+func (v fileExecsValue) Set(value string) error {
+	pairs := strings.Fields(value) // Split by spaces to handle multiple pairs
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return errors.New("invalid format for -file-exec. Expected '.ext=executable'")
+		}
+		v[parts[0]] = parts[1]
+	}
+	return nil
+}
+
+// newFlagSet defines every command-line flag, storing parsed values in opts.
+func newFlagSet(opts *Options, defaultDelimiter string, defaults Defaults) *flag.FlagSet {
+	if opts.FileExecs == nil {
+		opts.FileExecs = make(map[string]string)
+	}
+	wrapCode := true
+	if defaults.WrapCode != nil {
+		wrapCode = *defaults.WrapCode
+	}
+	ignoreGitIgnore := false
+	if defaults.IgnoreGitIgnore != nil {
+		ignoreGitIgnore = *defaults.IgnoreGitIgnore
+	}
+	format := FormatMarkdown
+	if defaults.Format != nil {
+		format = *defaults.Format
+	}
+
+	fs := flag.NewFlagSet("go-file-extract", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var((*filesValue)(&opts.Files), "files", "Files or directories to process; .zip, .tar and .tar.gz archives expand to their entries, or to those selected after !, as in src.zip!cmd")
+	fs.BoolVar(&opts.AllowRemote, "allow-remote", false, "Fetch http and https URLs passed to -files")
+	fs.DurationVar(&opts.Timeout, "timeout", DefaultFetchTimeout, "Time limit for fetching each remote file; 0 disables it")
+	fs.Var((*stringsValue)(&opts.Diff), "diff", "Include a unified diff between two files; repeatable")
+	fs.StringVar(&opts.Preset, "preset", "", "Add the files of a named bundle from "+PresetsFileName)
+	fs.StringVar(&opts.IgnorePattern, "ignore-pattern", "", "Skip files matching the regex")
+	fs.Var((*stringsValue)(&opts.IncludePatterns), "include-pattern", "Only process files matching the regex (repeatable)")
+	fs.Var((*stringsValue)(&opts.ForceIncludePatterns), "force-include", "Process files matching the regex even if .gitignore, .gitattributes or the secret file list excludes them (repeatable)")
+	fs.Var((*stringsValue)(&opts.ExcludeDirs), "exclude-dir", "Skip files inside directories with this name, e.g. node_modules (repeatable)")
+	fs.IntVar(&opts.MaxDepth, "max-depth", -1, "How many subdirectory levels to read below a directory in -files; 0 reads only its own files, -1 is unlimited")
+	fs.StringVar(&opts.ManifestPattern, "manifest-pattern", "", "List files matching the regex with their size but without content")
+	fs.StringVar(&opts.Match, "match", "", "Only extract lines matching the regex, with -before and -after lines of context")
+	fs.IntVar(&opts.Before, "before", 0, "Lines of context kept before each -match line")
+	fs.IntVar(&opts.After, "after", 0, "Lines of context kept after each -match line")
+	fs.BoolVar(&opts.IgnoreGitIgnore, "ignore-gitignore", ignoreGitIgnore, "Do not apply .gitignore rules")
+	fs.BoolVar(&opts.RespectGitAttributes, "respect-gitattributes", false, "Skip files marked export-ignore in .gitattributes, as git archive does")
+	fs.StringVar(&opts.Delimiter, "delimiter", defaultDelimiter, "Delimiter written after each file")
+	fs.StringVar(&opts.DelimiterStyle, "delimiter-style", DelimiterStyleRaw, "Write the delimiter as is (raw), as a comment in each file's language (comment) or as a markdown rule (markdown)")
+	fs.BoolVar(&opts.NoTrailingDelimiter, "no-trailing-delimiter", false, "Do not write the delimiter after the last file or section")
+	fs.BoolVar(&opts.WrapCode, "wrap-code", wrapCode, "Wrap file content in code fences")
+	fs.StringVar(&opts.SaveName, "name", "", "Save the arguments under a name for this folder")
+	fs.StringVar(&opts.SaveGlobalName, "save-global", "", "Save the arguments under a name available in every folder")
+	fs.BoolVar(&opts.NoBackup, "no-backup", false, "Do not keep config.json.bak with the previous config when saving")
+	fs.Var((*namesValue)(&opts.ByName), "by-name", "Reuse arguments saved under a name, or a unique prefix or part of one; repeat or separate names with commas to merge them")
+	fs.StringVar(&opts.ExecCommand, "exec", "", "Executable run on every file")
+	fs.Var(fileExecsValue(opts.FileExecs), "file-exec", "Executables for specific file types as `.ext=command` pairs")
+	fs.BoolVar(&opts.TrimBlankLines, "trim-blank-lines", false, "Trim trailing whitespace and collapse blank lines")
+	fs.IntVar(&opts.TruncateFileBytes, "truncate-file-bytes", 0, "Keep only the start and end of files over N bytes; 0 keeps whole files")
+	fs.Float64Var(&opts.TruncateHeadRatio, "truncate-head-ratio", 0.5, "Share of -truncate-file-bytes kept from the start of a file")
+	fs.BoolVar(&opts.NormalizeEOL, "normalize-eol", false, "Convert CRLF and CR line endings to LF")
+	fs.StringVar(&opts.Prepend, "prepend", "", "Text written before the file contents, or @file to read it from a file")
+	fs.StringVar(&opts.Append, "append", "", "Text written after the file contents, or @file to read it from a file")
+	fs.StringVar(&opts.Format, "format", format, "Output layout: markdown, or xml with a <document> element per file")
+	fs.BoolVar(&opts.LineNumbers, "line-numbers", false, "With -format xml, add start_line and end_line to each document and number every line")
+	fs.StringVar(&opts.TemplateFile, "template-file", "", "Go template file that lays out the whole output from the extracted files")
+	fs.BoolVar(&opts.Tree, "tree", false, "Start the output with a directory tree of the files")
+	fs.BoolVar(&opts.TOC, "toc", false, "Start the output with a numbered list of the included files")
+	fs.DurationVar(&opts.ExecTimeout, "exec-timeout", DefaultExecTimeout, "Time limit for each executable; 0 disables it")
+	fs.IntVar(&opts.ExecMaxOutput, "exec-max-output", DefaultExecMaxOutput, "Bytes captured per executable output stream; 0 is unlimited")
+	fs.BoolVar(&opts.ExecStderr, "exec-stderr", true, "Include executable stderr after stdout")
+	fs.IntVar(&opts.ExecRetries, "exec-retries", 0, "Retry an executable that exits non-zero or times out up to N times")
+	fs.StringVar(&opts.PostExec, "post-exec", "", "Command that receives the whole output on stdin; its stdout becomes the output")
+	fs.BoolVar(&opts.Cache, "cache", false, "Reuse files processed by earlier runs while they are unchanged, caching them in the user cache directory")
+	fs.BoolVar(&opts.NoCache, "no-cache", false, "Turn off -cache, e.g. one saved with a configuration")
+	fs.BoolVar(&opts.ClearCache, "clear-cache", false, "Remove all cached files")
+	fs.IntVar(&opts.Jobs, "jobs", 1, "Run up to this many per-file executables at once; output keeps the file order")
+	fs.StringVar(&opts.ExecMode, "exec-mode", ExecModePerFile, "Run executables per-file, once in batch, or per-file on the content from stdin, replacing it with their output")
+	fs.StringVar(&opts.ExecLabelTemplate, "exec-label-template", DefaultExecLabelTemplate, "Go template for the line before executable output, with {{.Command}} and {{.Path}}; empty for no label")
+	fs.StringVar(&opts.ExecOutputPosition, "exec-output-position", ExecOutputAfter, "Place per-file executable output after or before the file, or in a separate section at the end")
+	fs.StringVar(&opts.Sort, "sort", SortNone, "Order files by path, name, size, ext, or none to keep the input order")
+	fs.BoolVar(&opts.DetectContent, "detect-content", false, "Guess the fence language of unknown file types from their content")
+	fs.BoolVar(&opts.GroupByLanguage, "group-by-language", false, "Group files by language under a header for each language")
+	fs.BoolVar(&opts.Count, "count", false, "Print file, byte, line and estimated token totals per language to stdout instead of the output")
+	fs.BoolVar(&opts.Summary, "summary", false, "End the output with file, line and estimated token counts")
+	fs.IntVar(&opts.ChunkBytes, "chunk-bytes", 0, "Write the output to numbered files of at most N bytes instead of the clipboard")
+	fs.StringVar(&opts.ChunkPrefix, "chunk-prefix", "output", "File name prefix for -chunk-bytes files, as in output.1.txt")
+	fs.BoolVar(&opts.Compress, "compress", false, "Gzip and base64-encode the output behind a "+CompressedPrefix+" prefix")
+	fs.BoolVar(&opts.Decompress, "decompress", false, "Decode -compress output read from stdin and print it")
+	fs.BoolVar(&opts.Metadata, "metadata", false, "Add each file's size and modification time to its header")
+	fs.BoolVar(&opts.Perms, "perms", false, "Add each file's permission bits and, on Unix, owner and group to its header")
+	fs.BoolVar(&opts.Redact, "redact", false, "Replace API keys, tokens and other secrets with "+RedactedPlaceholder)
+	fs.BoolVar(&opts.IncludeSecrets, "include-secrets", false, "Extract files such as .env and *.pem that are skipped by default")
+	fs.BoolVar(&opts.SignaturesOnly, "signatures-only", false, "Keep only declarations and function signatures of Go files, without function bodies")
+	fs.BoolVar(&opts.SquashImports, "squash-imports", false, "Collapse the import section of Go, Python, JavaScript and TypeScript files into one line")
+	fs.BoolVar(&opts.DedupeContent, "dedupe-content", false, "Extract files with identical content once and note the others as identical to it")
+	fs.BoolVar(&opts.SkipGenerated, "skip-generated", false, "Skip files whose first lines mark them as generated, e.g. \"// Code generated ... DO NOT EDIT.\"")
+	fs.BoolVar(&opts.IncludeMinified, "include-minified", false, "Extract files that look minified instead of skipping them")
+	fs.StringVar(&opts.FenceInfoTemplate, "fence-info-template", DefaultFenceInfoTemplate, "Go template for the text after the opening code fence, with {{.Path}} and {{.Language}}")
+	fs.IntVar(&opts.FenceLen, "fence-len", minFenceLen, "Minimum code fence length; fences grow past any run of the fence character in the file")
+	fs.StringVar(&opts.FenceStyle, "fence-style", FenceStyleBacktick, "Fence code with backtick (```) or tilde (~~~)")
+	fs.BoolVar(&opts.Relative, "relative", false, "Show file paths relative to -base-dir")
+	fs.BoolVar(&opts.RelativeToGitRoot, "relative-to-git-root", false, "Show file paths and match ignore files relative to the git repository root, or -base-dir outside a repository")
+	fs.StringVar(&opts.BaseDir, "base-dir", ".", "Directory that header paths and ignore files are relative to")
+	fs.BoolVar(&opts.FollowSymlinks, "follow-symlinks", false, "Follow symlinks when reading directories instead of skipping them")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Path to the config file (default ~/.config/go-file-extract/config.json, or $GFE_CONFIG)")
+	fs.BoolVar(&opts.NoClipboard, "no-clipboard", false, "Print the output to stdout instead of the clipboard")
+	fs.BoolVar(&opts.Hidden, "hidden", false, "Include dotfiles and dot-directories when reading directories")
+	fs.BoolVar(&opts.Strict, "strict", false, "Fail instead of skipping files that are missing or cannot be read")
+	fs.StringVar(&opts.ClipboardSelection, "clipboard-selection", SelectionClipboard, "Write to the clipboard or, on Linux and BSD, the primary selection")
+	fs.BoolVar(&opts.VerifyClipboard, "verify-clipboard", false, "Read the clipboard back after copying and warn if it does not hold the output")
+	fs.StringVar(&opts.ClipboardCmd, "clipboard-cmd", "", "Command that receives the output on stdin instead of the detected clipboard tool, e.g. wl-copy")
+	fs.BoolVar(&opts.Quiet, "quiet", false, "Silence informational messages")
+	fs.BoolVar(&opts.Verbose, "verbose", false, "Log why each file is included or skipped")
+	fs.StringVar(&opts.ExportConfig, "export-config", "", "Write the configurations saved for the current folder to a JSON file and exit")
+	fs.StringVar(&opts.ImportConfig, "import-config", "", "Save the configurations from a -export-config file for the current folder and exit")
+	fs.BoolVar(&opts.Init, "init", false, "Write an example config file to the config path and exit")
+	fs.BoolVar(&opts.Force, "force", false, "Let -init overwrite an existing config file, or -import-config replace saved names")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "Print the resolved options and configuration as JSON and exit")
+	fs.StringVar(&opts.Completion, "completion", "", "Print a completion script for bash, zsh or fish")
+	fs.BoolVar(&opts.ListSaved, "list-saved", false, "Print the names saved for the current folder, one per line")
+	fs.BoolVar(&opts.Help, "help", false, "Show this help")
+	fs.BoolVar(&opts.Version, "version", false, "Show the version")
+	return fs
+}
+
+// flagDefinitions returns a flag set used only to look up flag definitions.
+func flagDefinitions() *flag.FlagSet {
+	return newFlagSet(&Options{}, DefaultDelimiter, Defaults{})
+}
+
+// PrintUsage writes the usage text for all flags to w.
+func PrintUsage(w io.Writer) {
+	fs := flagDefinitions()
+	fmt.Fprint(w, usageHeader)
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+}
+
+// splitFlag returns the name of the flag in arg and whether it carries an
+// inline =value. The name is empty if arg is not a flag.
+func splitFlag(arg string) (name string, hasValue bool) {
+	if len(arg) < 2 || arg[0] != '-' || arg == "--" {
+		return "", false
+	}
+	name = strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+	name, _, hasValue = strings.Cut(name, "=")
+	return name, hasValue
+}
+
+// isBoolFlag reports whether the flag takes no value, like the standard bool flags.
+func isBoolFlag(f *flag.Flag) bool {
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && boolFlag.IsBoolFlag()
+}
+
+// flagValueCount returns how many of the arguments following args[i] are values of that flag.
+// -files takes every argument up to the next flag, and a bool flag takes a following
+// "true" or "false" so saved arguments like "-wrap-code false" keep working.
+func flagValueCount(args []string, i int) int {
+	name, hasValue := splitFlag(args[i])
+	if name == "" || hasValue {
+		return 0
+	}
+	f := flagDefinitions().Lookup(name)
+	if f == nil {
+		return 0
+	}
+
+	remaining := len(args) - i - 1
+	switch {
+	case name == "files":
+		count := 0
+		for count < remaining && !strings.HasPrefix(args[i+1+count], "-") {
+			count++
+		}
+		return count
+	case name == "diff":
+		return min(2, remaining)
+	case isBoolFlag(f):
+		if remaining > 0 && (args[i+1] == "true" || args[i+1] == "false") {
+			return 1
+		}
+		return 0
+	default:
+		return min(1, remaining)
+	}
+}
+
+// normalizeArgs rewrites the tool's multi-value and space-separated bool
+// syntax into the form understood by the flag package.
+func normalizeArgs(args []string) []string {
+	var normalized []string
+	for i := 0; i < len(args); i++ {
+		count := flagValueCount(args, i)
+		name, _ := splitFlag(args[i])
+		switch {
+		case count == 0:
+			normalized = append(normalized, args[i])
+		case name == "files" || name == "diff" || isBoolFlag(flagDefinitions().Lookup(name)):
+			for _, value := range args[i+1 : i+1+count] {
+				normalized = append(normalized, "-"+name+"="+value)
+			}
+			i += count
+		default:
+			normalized = append(normalized, args[i:i+count+1]...)
+			i += count
+		}
+	}
+	return normalized
+}
+
+// findFlagValue returns the value of the last occurrence of the named flag in args.
+func findFlagValue(args []string, name string) (value string, found bool) {
+	for i := 0; i < len(args); i++ {
+		count := flagValueCount(args, i)
+		if flagName, hasValue := splitFlag(args[i]); flagName == name {
+			if hasValue {
+				_, value, _ = strings.Cut(args[i], "=")
+				found = true
+			} else if count > 0 {
+				value = args[i+1]
+				found = true
+			}
+		}
+		i += count
+	}
+	return value, found
+}
+
+// FindInfoFlag returns the first -help, -version or -init flag in args, skipping flag values.
+func FindInfoFlag(args []string) string {
+	for i := 0; i < len(args); i++ {
+		switch name, _ := splitFlag(args[i]); name {
+		case "h", "help":
+			return "-help"
+		case "version":
+			return "-version"
+		case "init":
+			return "-init"
+		}
+		i += flagValueCount(args, i)
+	}
+	return ""
+}
+
+// FilterOutFlags removes the specified flags and their values from the arguments list.
+func FilterOutFlags(args []string, flags ...string) []string {
+	strip := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		name, _ := splitFlag(flag)
+		strip[name] = true
+	}
+
+	var filteredArgs []string
+	for i := 0; i < len(args); i++ {
+		count := flagValueCount(args, i)
+		if name, _ := splitFlag(args[i]); !strip[name] {
+			filteredArgs = append(filteredArgs, args[i:i+count+1]...)
+		}
+		// Skip the flag's values so they are never mistaken for flags
+		i += count
+	}
+	return filteredArgs
+}
+
+// ParseArguments parses command-line arguments into Options.
+func ParseArguments(args []string, defaultDelimiter string, defaults Defaults) (*Options, error) {
+	opts := &Options{}
+	fs := newFlagSet(opts, defaultDelimiter, defaults)
+	if err := fs.Parse(normalizeArgs(args)); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unknown argument: %s", fs.Arg(0))
+	}
+
+	if opts.ExecMaxOutput < 0 {
+		return nil, errors.New("invalid value for -exec-max-output. Expected a non-negative byte count")
+	}
+	if len(opts.Diff)%2 != 0 {
+		return nil, errors.New("invalid value for -diff. Expected two files")
+	}
+	if opts.TruncateFileBytes < 0 {
+		return nil, errors.New("invalid value for -truncate-file-bytes. Expected a non-negative byte count")
+	}
+	if opts.TruncateHeadRatio < 0 || opts.TruncateHeadRatio > 1 {
+		return nil, errors.New("invalid value for -truncate-head-ratio. Expected a number from 0 to 1")
+	}
+	if opts.ChunkBytes < 0 {
+		return nil, errors.New("invalid value for -chunk-bytes. Expected a non-negative byte count")
+	}
+	if opts.ExecRetries < 0 {
+		return nil, errors.New("invalid value for -exec-retries. Expected a non-negative count")
+	}
+	if !slices.Contains(execModes, opts.ExecMode) {
+		return nil, fmt.Errorf("invalid value for -exec-mode: %s. Expected one of %s", opts.ExecMode, strings.Join(execModes, ", "))
+	}
+	if !slices.Contains(execOutputPositions, opts.ExecOutputPosition) {
+		return nil, fmt.Errorf("invalid value for -exec-output-position: %s. Expected one of %s", opts.ExecOutputPosition, strings.Join(execOutputPositions, ", "))
+	}
+	if info, err := os.Stat(opts.BaseDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("invalid value for -base-dir: %s is not a directory", opts.BaseDir)
+	}
+	if opts.ClipboardSelection != SelectionClipboard && opts.ClipboardSelection != SelectionPrimary {
+		return nil, fmt.Errorf("invalid value for -clipboard-selection: %s. Expected '%s' or '%s'", opts.ClipboardSelection, SelectionClipboard, SelectionPrimary)
+	}
+	if opts.Before < 0 || opts.After < 0 {
+		return nil, errors.New("invalid value for -before or -after. Expected a non-negative line count")
+	}
+	if opts.MaxDepth < -1 {
+		return nil, fmt.Errorf("invalid value for -max-depth: %d. Expected -1 or more", opts.MaxDepth)
+	}
+	if opts.FenceLen < minFenceLen {
+		return nil, fmt.Errorf("invalid value for -fence-len: %d. Expected at least %d", opts.FenceLen, minFenceLen)
+	}
+	if opts.FenceStyle != FenceStyleBacktick && opts.FenceStyle != FenceStyleTilde {
+		return nil, fmt.Errorf("invalid value for -fence-style: %s. Expected '%s' or '%s'", opts.FenceStyle, FenceStyleBacktick, FenceStyleTilde)
+	}
+	if _, err := parseFenceInfoTemplate(opts.FenceInfoTemplate); err != nil {
+		return nil, err
+	}
+	if _, err := parseExecLabelTemplate(opts.ExecLabelTemplate); err != nil {
+		return nil, err
+	}
+	if opts.Jobs < 1 {
+		return nil, fmt.Errorf("invalid value for -jobs: %d. Expected at least 1", opts.Jobs)
+	}
+	if !slices.Contains(formats, opts.Format) {
+		return nil, fmt.Errorf("invalid value for -format: %s. Expected one of %s", opts.Format, strings.Join(formats, ", "))
+	}
+	if opts.Format == FormatXML && opts.TemplateFile != "" {
+		return nil, errors.New("-format xml and -template-file cannot be used together")
+	}
+	if opts.VerifyClipboard && opts.ClipboardCmd != "" {
+		return nil, errors.New("-verify-clipboard cannot be used with -clipboard-cmd")
+	}
+	if opts.LineNumbers && opts.Format != FormatXML {
+		return nil, errors.New("-line-numbers requires -format xml")
+	}
+	if !slices.Contains(delimiterStyles, opts.DelimiterStyle) {
+		return nil, fmt.Errorf("invalid value for -delimiter-style: %s. Expected one of %s", opts.DelimiterStyle, strings.Join(delimiterStyles, ", "))
+	}
+	if opts.Completion != "" && !slices.Contains(completionShells, opts.Completion) {
+		return nil, fmt.Errorf("invalid value for -completion: %s. Expected one of %s", opts.Completion, strings.Join(completionShells, ", "))
+	}
+	if !slices.Contains(sortKeys, opts.Sort) {
+		return nil, fmt.Errorf("invalid value for -sort: %s. Expected one of %s", opts.Sort, strings.Join(sortKeys, ", "))
+	}
+	return opts, nil
+}
@@ -0,0 +1,115 @@
+package extract
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFilterOutFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		flags []string
+		want  []string
+	}{
+		{
+			name:  "name and by-name",
+			args:  []string{"-files", "a.go", "b.go", "-name", "go", "-by-name", "old", "-wrap-code"},
+			flags: []string{"-name", "-by-name"},
+			want:  []string{"-files", "a.go", "b.go", "-wrap-code"},
+		},
+		{
+			name:  "equals syntax",
+			args:  []string{"-name=go", "-files", "a.go", "--by-name=old"},
+			flags: []string{"-name", "-by-name"},
+			want:  []string{"-files", "a.go"},
+		},
+		{
+			name:  "flag not present",
+			args:  []string{"-files", "a.go", "-delimiter", "---"},
+			flags: []string{"-name"},
+			want:  []string{"-files", "a.go", "-delimiter", "---"},
+		},
+		{
+			name:  "value that looks like a stripped flag",
+			args:  []string{"-delimiter", "-name", "-files", "a.go"},
+			flags: []string{"-name"},
+			want:  []string{"-delimiter", "-name", "-files", "a.go"},
+		},
+		{
+			name:  "files after a stripped flag",
+			args:  []string{"-name", "go", "-files", "a.go", "b.go", "c.go", "d.go"},
+			flags: []string{"-name"},
+			want:  []string{"-files", "a.go", "b.go", "c.go", "d.go"},
+		},
+		{
+			name:  "stripped flag between file lists",
+			args:  []string{"-files", "a.go", "b.go", "-by-name", "old", "-files", "c.go", "d.go"},
+			flags: []string{"-by-name"},
+			want:  []string{"-files", "a.go", "b.go", "-files", "c.go", "d.go"},
+		},
+		{
+			name:  "stripping files",
+			args:  []string{"-files", "a.go", "b.go", "c.go", "-wrap-code", "false", "-name", "go"},
+			flags: []string{"-files"},
+			want:  []string{"-wrap-code", "false", "-name", "go"},
+		},
+		{
+			name:  "diff takes two values",
+			args:  []string{"-diff", "old.go", "new.go", "-files", "a.go", "b.go"},
+			flags: []string{"-diff"},
+			want:  []string{"-files", "a.go", "b.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FilterOutFlags(tt.args, tt.flags...); !slices.Equal(got, tt.want) {
+				t.Errorf("FilterOutFlags(%q, %q) = %q, want %q", tt.args, tt.flags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlagValueCount(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"files", []string{"-files", "a.go", "b.go", "c.go"}, 3},
+		{"files up to next flag", []string{"-files", "a.go", "b.go", "-wrap-code"}, 2},
+		{"files without values", []string{"-files", "-wrap-code"}, 0},
+		{"files with equals", []string{"-files=a.go", "b.go"}, 0},
+		{"diff", []string{"-diff", "old.go", "new.go", "c.go"}, 2},
+		{"bool with value", []string{"-wrap-code", "false", "a.go"}, 1},
+		{"bool without value", []string{"-wrap-code", "a.go"}, 0},
+		{"string", []string{"-delimiter", "-files"}, 1},
+		{"string at end", []string{"-delimiter"}, 0},
+		{"unknown flag", []string{"-nope", "a.go"}, 0},
+		{"not a flag", []string{"a.go", "b.go"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flagValueCount(tt.args, 0); got != tt.want {
+				t.Errorf("flagValueCount(%q, 0) = %d, want %d", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArgumentsClipboardConflicts(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{[]string{"-files", "a.go", "-verify-clipboard"}, false},
+		{[]string{"-files", "a.go", "-clipboard-cmd", "wl-copy"}, false},
+		{[]string{"-files", "a.go", "-verify-clipboard", "-clipboard-cmd", "wl-copy"}, true},
+	}
+	for _, tt := range tests {
+		_, err := ParseArguments(tt.args, DefaultDelimiter, Defaults{})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseArguments(%q) error = %v, want error %t", tt.args, err, tt.wantErr)
+		}
+	}
+}
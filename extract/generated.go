@@ -0,0 +1,54 @@
+package extract
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// DefaultGeneratedPatterns match the header comments code generators write
+// near the top of their files. The generated_patterns config key replaces the list.
+var DefaultGeneratedPatterns = []string{
+	// The Go convention, as in go generate and protoc-gen-go
+	`^// Code generated .* DO NOT EDIT\.$`,
+	// protoc output for other languages
+	`(?i)generated by the protocol buffer compiler.*do not edit`,
+	// Facebook's convention, used by Buck, Relay and others
+	`@generated\b`,
+	// Common wording of other generators
+	`(?i)auto-?generated.*do not (edit|modify)`,
+}
+
+// generatedHeaderLines is how many lines at the start of a file are checked
+// for a generated marker.
+const generatedHeaderLines = 10
+
+// compileGeneratedPatterns compiles the -skip-generated marker patterns.
+func compileGeneratedPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	markers := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid generated pattern %q: %v", pattern, err)
+		}
+		markers[i] = re
+	}
+	return markers, nil
+}
+
+// isGenerated reports whether one of the first generatedHeaderLines lines of
+// content matches a marker.
+func isGenerated(content []byte, markers []*regexp.Regexp) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, len(content)+1)
+	for n := 0; n < generatedHeaderLines && scanner.Scan(); n++ {
+		line := bytes.TrimRight(scanner.Bytes(), "\r")
+		for _, marker := range markers {
+			if marker.Match(line) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package extract
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsGenerated(t *testing.T) {
+	markers, err := compileGeneratedPatterns(DefaultGeneratedPatterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"empty", "", false},
+		{"hand-written go", "// Package a does things.\npackage a\n", false},
+		{"go generate", "// Code generated by stringer -type=Kind; DO NOT EDIT.\n\npackage a\n", true},
+		{"protoc-gen-go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n// versions:\n// \tprotoc-gen-go v1.34.2\npackage pb\n", true},
+		{"go marker with crlf", "// Code generated by mockgen. DO NOT EDIT.\r\npackage a\r\n", true},
+		{"go marker not at line start", "x // Code generated by hand. DO NOT EDIT.\n", false},
+		{"go marker without period", "// Code generated by tool. DO NOT EDIT\n", false},
+		{"protobuf c++ header", "// Generated by the protocol buffer compiler.  DO NOT EDIT!\n// source: a.proto\n", true},
+		{"protobuf python header", "# -*- coding: utf-8 -*-\n# Generated by the protocol buffer compiler.  DO NOT EDIT!\n# source: a.proto\n", true},
+		{"@generated", "/**\n * @generated SignedSource<<abc>>\n */\n", true},
+		{"auto-generated", "# This file is auto-generated; do not modify.\n", true},
+		{"marker below the header", strings.Repeat("line\n", generatedHeaderLines) + "// Code generated by x. DO NOT EDIT.\n", false},
+		{"marker on the last header line", strings.Repeat("line\n", generatedHeaderLines-1) + "// Code generated by x. DO NOT EDIT.\n", true},
+		{"mentions generated code", "// This package parses generated code.\npackage a\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isGenerated([]byte(tt.content), markers); got != tt.want {
+				t.Errorf("isGenerated(%q) = %t, want %t", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileGeneratedPatternsError(t *testing.T) {
+	if _, err := compileGeneratedPatterns([]string{`(unclosed`}); err == nil {
+		t.Error("compileGeneratedPatterns succeeded for an invalid pattern, want an error")
+	}
+}
@@ -0,0 +1,94 @@
+package extract
+
+import (
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// expandGlob returns the paths matching pattern in lexical order. Besides the
+// filepath.Match syntax, a ** path element matches any number of directories.
+func expandGlob(pattern string) ([]string, error) {
+	pattern = filepath.Clean(pattern)
+	if !strings.Contains(pattern, "**") {
+		return filepath.Glob(pattern)
+	}
+	re, err := globRegexp(filepath.ToSlash(pattern))
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	err = filepath.WalkDir(globRoot(pattern), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Unreadable entries are reported when extracted, not here
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && re.MatchString(filepath.ToSlash(path)) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// globRoot returns the leading directories of pattern that contain no glob
+// characters, which is where a walk for its matches starts.
+func globRoot(pattern string) string {
+	var root []string
+	for _, part := range strings.Split(filepath.ToSlash(pattern), "/") {
+		if strings.ContainsAny(part, "*?[") {
+			break
+		}
+		root = append(root, part)
+	}
+	if len(root) == 0 {
+		return "."
+	}
+	if len(root) == 1 && root[0] == "" {
+		return "/"
+	}
+	return filepath.FromSlash(strings.Join(root, "/"))
+}
+
+// globRegexp converts a slash-separated glob with ** support to a regexp.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				b.WriteString("(?:.*/)?")
+				i += 2
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta(pattern[i:]))
+				i = len(pattern)
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,248 @@
+package extract
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// ExtractIgnoreName is an ignore file with .gitignore syntax that applies
+// whether or not the directory is a git repository.
+const ExtractIgnoreName = ".extractignore"
+
+// exportIgnoreAttribute marks paths that git archive leaves out.
+const exportIgnoreAttribute = "export-ignore"
+
+// ignoreRules holds the compiled .gitignore and .extractignore matchers for a
+// directory, so repeated extractions can reuse them until an ignore file changes.
+type ignoreRules struct {
+	root             string
+	useGitIgnore     bool
+	useGitAttributes bool
+	gitIgnore        gitignore.Matcher     // Nil outside a git repository or with -ignore-gitignore
+	extract          gitignore.Matcher     // Nil without an .extractignore file
+	gitAttributes    gitattributes.Matcher // Nil without -respect-gitattributes or a .gitattributes file
+	read             []string              // Ignore files and directories read while walking root
+	modTimes         map[string]time.Time
+}
+
+// recordingFS records the files opened and directories listed through it, so
+// the nested .gitignore and .gitattributes files go-git reads can be watched.
+// A directory is recorded because adding an ignore file to it changes its
+// modification time.
+type recordingFS struct {
+	billy.Filesystem
+	root     string
+	read     *[]string
+	modTimes map[string]time.Time
+}
+
+// record notes path, relative to the root, with its current modification time.
+func (fs recordingFS) record(path string) {
+	path = filepath.Join(fs.root, path)
+	if info, err := os.Stat(path); err == nil {
+		*fs.read = append(*fs.read, path)
+		fs.modTimes[path] = info.ModTime()
+	}
+}
+
+func (fs recordingFS) Open(filename string) (billy.File, error) {
+	fs.record(filename)
+	return fs.Filesystem.Open(filename)
+}
+
+func (fs recordingFS) ReadDir(path string) ([]os.FileInfo, error) {
+	fs.record(path)
+	return fs.Filesystem.ReadDir(path)
+}
+
+// newIgnoreRules reads the ignore files under root, and the .gitattributes
+// files if useGitAttributes is set. Files that cannot be read are reported and
+// skipped so extraction can continue.
+func newIgnoreRules(root string, useGitIgnore, useGitAttributes bool) *ignoreRules {
+	rules := &ignoreRules{root: root, useGitIgnore: useGitIgnore, useGitAttributes: useGitAttributes}
+	rules.modTimes = rules.watchedModTimes()
+	fs := recordingFS{Filesystem: osfs.New(root), root: root, read: &rules.read, modTimes: rules.modTimes}
+
+	if useGitIgnore {
+		_, err := git.PlainOpenWithOptions(root, &git.PlainOpenOptions{DetectDotGit: true})
+		if err == nil {
+			patterns, err := gitignore.ReadPatterns(fs, []string{})
+			if err != nil {
+				log.Printf("Error reading .gitignore patterns: %v", err)
+			} else {
+				rules.gitIgnore = gitignore.NewMatcher(patterns)
+			}
+		}
+	}
+
+	if useGitAttributes {
+		attributes, err := gitattributes.ReadPatterns(fs, nil)
+		if err != nil {
+			log.Printf("Error reading .gitattributes patterns: %v", err)
+		} else if len(attributes) > 0 {
+			rules.gitAttributes = gitattributes.NewMatcher(attributes)
+		}
+	}
+
+	patterns, err := readIgnoreFile(filepath.Join(root, ExtractIgnoreName))
+	if err != nil {
+		log.Printf("Error reading %s patterns: %v", ExtractIgnoreName, err)
+	} else if len(patterns) > 0 {
+		rules.extract = gitignore.NewMatcher(patterns)
+	}
+	return rules
+}
+
+// gitRoot returns the top directory of the git repository containing dir, or
+// "" if dir is not inside a repository with a working tree.
+func gitRoot(dir string) string {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return ""
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return ""
+	}
+	return worktree.Filesystem.Root()
+}
+
+// watchedFiles lists the ignore files at the root whose changes invalidate
+// the rules, whether or not they exist. The nested files read are watched too;
+// see stale.
+func (r *ignoreRules) watchedFiles() []string {
+	files := []string{filepath.Join(r.root, ExtractIgnoreName)}
+	if r.useGitIgnore {
+		files = append(files, filepath.Join(r.root, ".gitignore"), filepath.Join(r.root, ".git", "info", "exclude"))
+	}
+	if r.useGitAttributes {
+		files = append(files, filepath.Join(r.root, ".gitattributes"))
+	}
+	return files
+}
+
+// watchedModTimes returns the modification time of each watched file that exists.
+func (r *ignoreRules) watchedModTimes() map[string]time.Time {
+	modTimes := make(map[string]time.Time)
+	for _, path := range r.watchedFiles() {
+		if info, err := os.Stat(path); err == nil {
+			modTimes[path] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+// stale reports whether an ignore file was added, removed or modified since
+// the rules were read, at the root or in any directory walked for them.
+func (r *ignoreRules) stale() bool {
+	for _, path := range slices.Concat(r.watchedFiles(), r.read) {
+		modTime, existed := r.modTimes[path]
+		info, err := os.Stat(path)
+		if (err == nil) != existed || (existed && !info.ModTime().Equal(modTime)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the name of the ignore file that excludes path, or "" if the
+// path is not ignored. path is matched relative to the rules' root.
+func (r *ignoreRules) Match(path string) (string, error) {
+	if r.gitIgnore == nil && r.extract == nil && r.gitAttributes == nil {
+		return "", nil
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	absRoot, err := filepath.Abs(r.root)
+	if err != nil {
+		return "", err
+	}
+	relPath, err := filepath.Rel(absRoot, absPath)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	if r.gitIgnore != nil && r.gitIgnore.Match(parts, false) {
+		return ".gitignore", nil
+	}
+	if r.extract != nil && r.extract.Match(parts, false) {
+		return ExtractIgnoreName, nil
+	}
+	if r.gitAttributes != nil && r.exportIgnored(parts) {
+		return ".gitattributes", nil
+	}
+	return "", nil
+}
+
+// exportIgnored reports whether the export-ignore attribute is set for the
+// path or, as git archive treats it, for a directory containing it.
+func (r *ignoreRules) exportIgnored(parts []string) bool {
+	for i := 1; i <= len(parts); i++ {
+		results, matched := r.gitAttributes.Match(parts[:i], []string{exportIgnoreAttribute})
+		if matched && results[exportIgnoreAttribute].IsSet() {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedDir returns the first directory in path whose name is one of
+// names, or "" if none is. The file name itself is not checked.
+func excludedDir(path string, names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	dir := filepath.Dir(filepath.Clean(path))
+	for _, part := range strings.Split(filepath.ToSlash(dir), "/") {
+		if slices.Contains(names, part) {
+			return part
+		}
+	}
+	return ""
+}
+
+// readIgnoreFile parses the patterns in a .gitignore-style file. A missing
+// file yields no patterns.
+func readIgnoreFile(path string) ([]gitignore.Pattern, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []gitignore.Pattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, nil))
+	}
+	return patterns, scanner.Err()
+}
+
+// ignoreRules returns the ignore rules for root, reusing the rules from a
+// previous call unless an ignore file has changed since.
+func (app *App) ignoreRules(root string, useGitIgnore, useGitAttributes bool) *ignoreRules {
+	if app.ignores == nil || app.ignores.root != root || app.ignores.useGitIgnore != useGitIgnore || app.ignores.useGitAttributes != useGitAttributes || app.ignores.stale() {
+		app.ignores = newIgnoreRules(root, useGitIgnore, useGitAttributes)
+	}
+	return app.ignores
+}
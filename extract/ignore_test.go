@@ -0,0 +1,183 @@
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// extractedHeaders returns the header lines of output, given the files that
+// could appear in it.
+func extractedHeaders(output string, names []string) []string {
+	var headers []string
+	for _, line := range strings.Split(output, "\n") {
+		if slices.Contains(names, line) {
+			headers = append(headers, line)
+		}
+	}
+	return headers
+}
+
+func TestExtractForceIncludeGitIgnore(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := git.PlainInit(dir, false); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"a.go", "debug.log", "trace.log", "build/out.go"}
+	writeFiles(t, dir, map[string]string{
+		".gitignore":   "*.log\nbuild/\n",
+		"a.go":         "package a\n",
+		"debug.log":    "debug\n",
+		"trace.log":    "trace\n",
+		"build/out.go": "package build\n",
+	})
+	var files []string
+	for _, name := range names {
+		files = append(files, filepath.Join(dir, name))
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"gitignore applies", nil, []string{"a.go"}},
+		{"force-include bypasses gitignore", []string{"-force-include", `debug\.log$`}, []string{"a.go", "debug.log"}},
+		{"force-include an ignored directory", []string{"-force-include", `/build/`}, []string{"a.go", "build/out.go"}},
+		{"ignore-pattern beats force-include", []string{"-force-include", `\.log$`, "-ignore-pattern", `trace`}, []string{"a.go", "debug.log"}},
+		{"ignore-gitignore", []string{"-ignore-gitignore"}, names},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractIn(t, dir, slices.Concat([]string{"-files"}, files, tt.args)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if headers := extractedHeaders(got, names); !slices.Equal(headers, tt.want) {
+				t.Errorf("extracted %q, want %q", headers, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractGitAttributesExportIgnore(t *testing.T) {
+	names := []string{"a.go", "a_test.go", "testdata/in.txt", "docs/guide.md", "docs/keep.md"}
+	files := map[string]string{
+		"a.go":            "package a\n",
+		"a_test.go":       "package a\n",
+		"testdata/in.txt": "input\n",
+		"docs/guide.md":   "# Guide\n",
+		"docs/keep.md":    "# Keep\n",
+	}
+
+	tests := []struct {
+		name       string
+		attributes string // Content of .gitattributes; empty for none
+		args       []string
+		want       []string
+	}{
+		{
+			name:       "export-ignore",
+			attributes: "*_test.go export-ignore\ntestdata export-ignore\ndocs/guide.md export-ignore\n",
+			args:       []string{"-respect-gitattributes"},
+			want:       []string{"a.go", "docs/keep.md"},
+		},
+		{
+			name:       "unset attribute",
+			attributes: "*.md export-ignore\ndocs/keep.md -export-ignore\n",
+			args:       []string{"-respect-gitattributes"},
+			want:       []string{"a.go", "a_test.go", "testdata/in.txt", "docs/keep.md"},
+		},
+		{
+			name:       "flag not set",
+			attributes: "*_test.go export-ignore\n",
+			want:       names,
+		},
+		{
+			name: "no attributes file",
+			args: []string{"-respect-gitattributes"},
+			want: names,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, files)
+			if tt.attributes != "" {
+				writeFiles(t, dir, map[string]string{".gitattributes": tt.attributes})
+			}
+			args := []string{"-files"}
+			for _, name := range names {
+				args = append(args, filepath.Join(dir, name))
+			}
+			got, err := extractIn(t, dir, append(args, tt.args...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if headers := extractedHeaders(got, names); !slices.Equal(headers, tt.want) {
+				t.Errorf("extracted %q, want %q", headers, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractNestedGitIgnoreChanges(t *testing.T) {
+	names := []string{"a.go", "sub/b.go", "sub/c.go"}
+	tests := []struct {
+		name   string
+		before string // Content of sub/.gitignore on the first run; empty for none
+		after  string // Content on the second run; empty to remove it
+		want   []string
+	}{
+		{"edited", "b.go\n", "c.go\n", []string{"a.go", "sub/b.go"}},
+		{"added", "", "c.go\n", []string{"a.go", "sub/b.go"}},
+		{"removed", "c.go\n", "", names},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if _, err := git.PlainInit(dir, false); err != nil {
+				t.Fatal(err)
+			}
+			writeFiles(t, dir, map[string]string{"a.go": "package a\n", "sub/b.go": "package sub\n", "sub/c.go": "package sub\n"})
+			ignorePath := filepath.Join(dir, "sub", ".gitignore")
+			if tt.before != "" {
+				writeFiles(t, dir, map[string]string{"sub/.gitignore": tt.before})
+			}
+			var files []string
+			for _, name := range names {
+				files = append(files, filepath.Join(dir, name))
+			}
+			app, opts := parseIn(t, dir, append([]string{"-files"}, files...)...)
+			if _, err := app.Extract(opts); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.after != "" {
+				writeFiles(t, dir, map[string]string{"sub/.gitignore": tt.after})
+			} else if err := os.Remove(ignorePath); err != nil {
+				t.Fatal(err)
+			}
+			// Make the change visible whatever the timestamp resolution
+			later := time.Now().Add(time.Hour)
+			for _, path := range []string{ignorePath, filepath.Dir(ignorePath)} {
+				if err := os.Chtimes(path, later, later); err != nil && !os.IsNotExist(err) {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := app.Extract(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if headers := extractedHeaders(got, names); !slices.Equal(headers, tt.want) {
+				t.Errorf("second run extracted %q, want %q", headers, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package extract
+
+import (
+	"regexp"
+	"strings"
+)
+
+// importsOmitted replaces the import section with -squash-imports.
+const importsOmitted = "... imports omitted ..."
+
+// importStatement returns how many lines the import statement starting at
+// lines[i] spans, or 0 if lines[i] does not start one.
+type importStatement func(lines []string, i int) int
+
+// importStatements holds the import syntax of the languages -squash-imports
+// supports, keyed by language name.
+var importStatements = map[string]importStatement{
+	"go":         goImport,
+	"python":     pythonImport,
+	"javascript": jsImport,
+	"typescript": jsImport,
+}
+
+// squashImports collapses the first run of import statements in text into a
+// single comment line. Blank lines and comments between statements belong to
+// the run. Text in other languages, or without imports, is returned unchanged.
+func squashImports(text, language string) string {
+	statement, ok := importStatements[language]
+	if !ok {
+		return text
+	}
+	comment := lineComments[language]
+	isComment := func(line string) bool {
+		return strings.HasPrefix(strings.TrimSpace(line), strings.TrimSpace(comment.Open))
+	}
+
+	lines := strings.Split(text, "\n")
+	start := -1
+	for i := range lines {
+		if statement(lines, i) > 0 {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return text
+	}
+
+	// Extend the run over further statements, ending it after the last one
+	end := start
+	for i := start; i < len(lines); {
+		if n := statement(lines, i); n > 0 {
+			i += n
+			end = i
+		} else if strings.TrimSpace(lines[i]) == "" || isComment(lines[i]) {
+			i++
+		} else {
+			break
+		}
+	}
+
+	squashed := append(lines[:start:start], comment.Open+importsOmitted+comment.Close)
+	return strings.Join(append(squashed, lines[end:]...), "\n")
+}
+
+// statementUntil returns the number of lines from lines[i] through the first
+// line that done accepts, or 0 if none does.
+func statementUntil(lines []string, i int, done func(line string) bool) int {
+	for j := i; j < len(lines); j++ {
+		if done(lines[j]) {
+			return j - i + 1
+		}
+	}
+	return 0
+}
+
+// goImport recognizes import declarations, single or grouped in parentheses.
+func goImport(lines []string, i int) int {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(lines[i]), "import")
+	if !ok || rest == "" || !strings.ContainsAny(rest[:1], " \t(\"`") {
+		return 0
+	}
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, "(") || strings.Contains(rest, ")") {
+		return 1
+	}
+	return statementUntil(lines, i, func(line string) bool {
+		return strings.TrimSpace(line) == ")"
+	})
+}
+
+// pythonImport recognizes top-level import and from ... import statements,
+// including ones continued in parentheses or with backslashes.
+func pythonImport(lines []string, i int) int {
+	line := lines[i]
+	if !strings.HasPrefix(line, "import ") && !(strings.HasPrefix(line, "from ") && strings.Contains(line, " import")) {
+		return 0
+	}
+	if strings.Contains(line, "(") && !strings.Contains(line, ")") {
+		return statementUntil(lines, i, func(line string) bool {
+			return strings.Contains(line, ")")
+		})
+	}
+	return statementUntil(lines, i, func(line string) bool {
+		return !strings.HasSuffix(strings.TrimRight(line, " \t"), "\\")
+	})
+}
+
+// jsModuleSource matches the end of an import statement naming its module.
+var jsModuleSource = regexp.MustCompile(`(^import\s*|\bfrom\s*)['"][^'"]+['"]\s*;?\s*$`)
+
+// jsRequire matches a CommonJS require assigned to a variable.
+var jsRequire = regexp.MustCompile(`^(const|let|var)\s+.+=\s*require\(\s*['"][^'"]+['"]\s*\)\s*;?\s*$`)
+
+// jsImport recognizes ES module imports, including ones spread over several
+// lines, and CommonJS requires. Dynamic import() calls are not imports here.
+func jsImport(lines []string, i int) int {
+	line := strings.TrimSpace(lines[i])
+	if jsRequire.MatchString(line) {
+		return 1
+	}
+	if !strings.HasPrefix(line, "import") || strings.HasPrefix(line, "import(") {
+		return 0
+	}
+	if rest := strings.TrimPrefix(line, "import"); rest != "" && !strings.ContainsAny(rest[:1], " \t{*'\"") {
+		return 0 // An identifier such as importFoo
+	}
+	n := statementUntil(lines, i, func(line string) bool {
+		line = strings.TrimSpace(line)
+		return jsModuleSource.MatchString(line) || strings.HasSuffix(line, ";")
+	})
+	// Without a module source or semicolon, take just the first line
+	return max(n, 1)
+}
@@ -0,0 +1,80 @@
+package extract
+
+import "testing"
+
+func TestSquashImports(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		text     string
+		want     string
+	}{
+		{
+			name:     "go grouped",
+			language: "go",
+			text:     "package a\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\nfunc main() {}\n",
+			want:     "package a\n\n// ... imports omitted ...\n\nfunc main() {}\n",
+		},
+		{
+			name:     "go single and grouped",
+			language: "go",
+			text:     "package a\n\nimport \"fmt\"\n\n// Standard library\nimport (\n\t\"os\"\n)\nimport _ \"embed\"\n\nvar x = 1\n",
+			want:     "package a\n\n// ... imports omitted ...\n\nvar x = 1\n",
+		},
+		{
+			name:     "go one-line group",
+			language: "go",
+			text:     "package a\nimport (\"fmt\"; \"os\")\nfunc f() {}\n",
+			want:     "package a\n// ... imports omitted ...\nfunc f() {}\n",
+		},
+		{
+			name:     "go without imports",
+			language: "go",
+			text:     "package a\n\n// importance is a word\nvar importance = 1\n",
+			want:     "package a\n\n// importance is a word\nvar importance = 1\n",
+		},
+		{
+			name:     "python",
+			language: "python",
+			text:     "\"\"\"Module.\"\"\"\nimport os\nimport sys\nfrom typing import (\n    Any,\n    List,\n)\n# local\nfrom . import util\n\ndef main():\n    import json\n",
+			want:     "\"\"\"Module.\"\"\"\n# ... imports omitted ...\n\ndef main():\n    import json\n",
+		},
+		{
+			name:     "python backslash continuation",
+			language: "python",
+			text:     "from os.path import join, \\\n    exists\nx = 1\n",
+			want:     "# ... imports omitted ...\nx = 1\n",
+		},
+		{
+			name:     "python from without import",
+			language: "python",
+			text:     "from_date = 1\nimport os\n",
+			want:     "from_date = 1\n# ... imports omitted ...\n",
+		},
+		{
+			name:     "javascript",
+			language: "javascript",
+			text:     "import fs from 'fs';\nimport {\n  a,\n  b,\n} from \"./ab\";\nconst path = require('path');\n\nexport const x = 1;\n",
+			want:     "// ... imports omitted ...\n\nexport const x = 1;\n",
+		},
+		{
+			name:     "javascript dynamic import",
+			language: "javascript",
+			text:     "const m = import('./m');\n",
+			want:     "const m = import('./m');\n",
+		},
+		{
+			name:     "unsupported language",
+			language: "rust",
+			text:     "use std::fs;\n",
+			want:     "use std::fs;\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := squashImports(tt.text, tt.language); got != tt.want {
+				t.Errorf("squashImports(%q, %s) = %q, want %q", tt.text, tt.language, got, tt.want)
+			}
+		})
+	}
+}
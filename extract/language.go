@@ -0,0 +1,58 @@
+package extract
+
+import "path/filepath"
+
+// languageMap maps file extensions to the language names used in code fences.
+var languageMap = map[string]string{
+	".go":   "go",
+	".js":   "javascript",
+	".ts":   "typescript",
+	".fish": "fish",
+	".py":   "python",
+	".java": "java",
+	".cpp":  "cpp",
+	".c":    "c",
+	".html": "html",
+	".css":  "css",
+	".sh":   "bash",
+	".md":   "markdown",
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".rs":   "rust",
+	".php":  "php",
+	".rb":   "ruby",
+}
+
+// languageFor returns the language of a file based on its extension, or
+// "plaintext" if the extension is not known.
+func languageFor(path string) string {
+	if language, ok := languageMap[filepath.Ext(path)]; ok {
+		return language
+	}
+	return plaintextLanguage
+}
+
+// languageGroup is a run of files sharing the same language.
+type languageGroup struct {
+	Language string
+	Files    []sourceFile
+}
+
+// groupByLanguage splits files into one group per language. Groups appear in
+// the order their language is first seen and files keep their relative order.
+func groupByLanguage(files []sourceFile) []languageGroup {
+	var groups []languageGroup
+	index := make(map[string]int)
+	for _, file := range files {
+		language := languageFor(file.Path)
+		i, ok := index[language]
+		if !ok {
+			i = len(groups)
+			index[language] = i
+			groups = append(groups, languageGroup{Language: language})
+		}
+		groups[i].Files = append(groups[i].Files, file)
+	}
+	return groups
+}
@@ -0,0 +1,63 @@
+package extract
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Values accepted by -sort.
+const (
+	SortNone = "none" // Keep the order the files were given in
+	SortPath = "path" // Order by full path
+	SortName = "name" // Order by base name
+	SortSize = "size" // Order by size, smallest first
+	SortExt  = "ext"  // Group by extension, then order by path
+)
+
+// sortKeys lists every value accepted by -sort.
+var sortKeys = []string{SortNone, SortPath, SortName, SortSize, SortExt}
+
+// sortFiles orders files by the given -sort key. The sort is stable, so files
+// that compare equal keep their input order.
+func sortFiles(files []sourceFile, key string) {
+	switch key {
+	case SortPath:
+		slices.SortStableFunc(files, func(a, b sourceFile) int {
+			return strings.Compare(a.Path, b.Path)
+		})
+	case SortName:
+		slices.SortStableFunc(files, func(a, b sourceFile) int {
+			return strings.Compare(filepath.Base(a.Path), filepath.Base(b.Path))
+		})
+	case SortSize:
+		sizes := make(map[string]int64, len(files))
+		for _, file := range files {
+			sizes[file.Path] = fileSize(file)
+		}
+		slices.SortStableFunc(files, func(a, b sourceFile) int {
+			return cmp.Compare(sizes[a.Path], sizes[b.Path])
+		})
+	case SortExt:
+		slices.SortStableFunc(files, func(a, b sourceFile) int {
+			if c := strings.Compare(filepath.Ext(a.Path), filepath.Ext(b.Path)); c != 0 {
+				return c
+			}
+			return strings.Compare(a.Path, b.Path)
+		})
+	}
+}
+
+// fileSize returns the size of a file in bytes, or 0 if it cannot be determined.
+func fileSize(file sourceFile) int64 {
+	if file.InMemory {
+		return int64(len(file.Content))
+	}
+	info, err := os.Stat(file.Path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,43 @@
+package extract
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSortFiles(t *testing.T) {
+	memory := func(path, content string) sourceFile {
+		return sourceFile{Path: path, Content: []byte(content), InMemory: true}
+	}
+	files := []sourceFile{
+		memory("src/b.go", "12345"),
+		memory("docs/z.md", "1"),
+		memory("a.txt", "123"),
+		memory("src/a.md", "123"),
+		memory("lib/b.go", "12"),
+	}
+
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{SortNone, []string{"src/b.go", "docs/z.md", "a.txt", "src/a.md", "lib/b.go"}},
+		{SortPath, []string{"a.txt", "docs/z.md", "lib/b.go", "src/a.md", "src/b.go"}},
+		{SortName, []string{"src/a.md", "a.txt", "src/b.go", "lib/b.go", "docs/z.md"}},
+		{SortSize, []string{"docs/z.md", "lib/b.go", "a.txt", "src/a.md", "src/b.go"}},
+		{SortExt, []string{"lib/b.go", "src/b.go", "docs/z.md", "src/a.md", "a.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			sorted := slices.Clone(files)
+			sortFiles(sorted, tt.key)
+			var got []string
+			for _, file := range sorted {
+				got = append(got, file.Path)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sortFiles(%s) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package extract
+
+import "io"
+
+// outputWriter writes the output to w as it is assembled, so only the file
+// being processed is held in memory. It counts the bytes written for
+// -summary and holds back the latest delimiter line until more output
+// follows, so -no-trailing-delimiter can drop the last one. After a write
+// fails, later writes are skipped and Err reports the failure.
+type outputWriter struct {
+	w       io.Writer
+	written int
+	pending string // The latest delimiter line, not yet written
+	err     error
+}
+
+// WriteString writes s after any pending delimiter.
+func (o *outputWriter) WriteString(s string) {
+	o.flush()
+	o.write(s)
+}
+
+// WriteDelimiter writes the pending delimiter and holds back line, without
+// its newline, as the new pending delimiter.
+func (o *outputWriter) WriteDelimiter(line string) {
+	o.flush()
+	o.pending = line + "\n"
+}
+
+// EndSections writes or, with drop, discards the delimiter after the last
+// section.
+func (o *outputWriter) EndSections(drop bool) {
+	if drop {
+		o.pending = ""
+	}
+	o.flush()
+}
+
+// Written returns the number of bytes written so far, excluding a pending delimiter.
+func (o *outputWriter) Written() int {
+	return o.written
+}
+
+// Err returns the first error from writing to w.
+func (o *outputWriter) Err() error {
+	return o.err
+}
+
+func (o *outputWriter) flush() {
+	if o.pending != "" {
+		pending := o.pending
+		o.pending = ""
+		o.write(pending)
+	}
+}
+
+func (o *outputWriter) write(s string) {
+	if o.err != nil {
+		return
+	}
+	n, err := io.WriteString(o.w, s)
+	o.written += n
+	o.err = err
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package extract
+
+import "os"
+
+// fileOwner returns an empty string where files have no Unix owner and group.
+func fileOwner(info os.FileInfo) string {
+	return ""
+}
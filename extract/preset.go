@@ -0,0 +1,79 @@
+package extract
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// PresetsFileName is a repository-committed file mapping preset names to
+// lists of file globs, e.g. {"backend": ["cmd/**/*.go", "go.mod"]}.
+const PresetsFileName = ".extract-presets.json"
+
+// presetFiles returns the paths matched by the globs of the named preset in
+// the nearest presets file above dir. Globs are relative to the presets file
+// and may use ** to match any number of directories.
+func presetFiles(dir, name string) ([]string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	path, found := findProjectFile(absDir, PresetsFileName)
+	if !found {
+		return nil, fmt.Errorf("preset '%s' requested but no %s was found", name, PresetsFileName)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	var presets map[string][]string
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	globs, ok := presets[name]
+	if !ok {
+		var names []string
+		for presetName := range presets {
+			names = append(names, presetName)
+		}
+		slices.Sort(names)
+		return nil, fmt.Errorf("unknown preset '%s' in %s. Available presets: %s", name, path, strings.Join(names, ", "))
+	}
+
+	presetDir := filepath.Dir(path)
+	var files []string
+	for _, glob := range globs {
+		if !filepath.IsAbs(glob) {
+			glob = filepath.Join(presetDir, glob)
+		}
+		matches, err := expandGlob(glob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s' in preset '%s': %v", glob, name, err)
+		}
+		for _, match := range matches {
+			files = append(files, relativeToDir(match, dir))
+		}
+	}
+	return files, nil
+}
+
+// relativeToDir returns path relative to dir when possible, so headers stay
+// short; otherwise it returns path unchanged.
+func relativeToDir(path, dir string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return path
+	}
+	return rel
+}
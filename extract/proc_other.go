@@ -0,0 +1,9 @@
+//go:build !unix
+
+package extract
+
+import "os/exec"
+
+// killProcessGroup leaves cmd as it is where there are no Unix process
+// groups; cancelling its context kills only the command itself.
+func killProcessGroup(cmd *exec.Cmd) {}
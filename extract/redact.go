@@ -0,0 +1,138 @@
+package extract
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RedactedPlaceholder replaces every secret removed by -redact.
+const RedactedPlaceholder = "[REDACTED]"
+
+// DefaultSecretFiles are base-name patterns of files skipped unless
+// -include-secrets is passed. The secret_files config key replaces the list.
+var DefaultSecretFiles = []string{
+	".env", ".env.*", "*.pem", "*.key", "*.p12", "*.pfx",
+	"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519",
+}
+
+// minTokenEntropy is the Shannon entropy, in bits per character, above which a
+// long token is treated as a generated secret.
+const minTokenEntropy = 4.0
+
+// redactRule finds one kind of secret. If the pattern has a capture group,
+// only the first group is replaced so surrounding context such as a variable
+// name stays readable. check, when set, must accept a match before it is redacted.
+type redactRule struct {
+	re    *regexp.Regexp
+	check func(string) bool
+}
+
+// builtinRedactRules are the secret patterns applied by -redact.
+var builtinRedactRules = []redactRule{
+	// Private key blocks
+	{re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	// AWS access key IDs
+	{re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	// GitHub and Slack tokens
+	{re: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	// .env style assignments to secret-looking names, e.g. DB_PASSWORD=hunter2
+	{re: regexp.MustCompile(`(?im)^\s*(?:export\s+)?[A-Z0-9_]*(?:SECRET|TOKEN|PASSWORD|PASSWD|API_?KEY|ACCESS_?KEY|PRIVATE_?KEY)[A-Z0-9_]*\s*[=:]\s*["']?([^\s"']+)`)},
+	// Long random-looking tokens
+	{re: regexp.MustCompile(`[A-Za-z0-9+/_=-]{32,}`), check: isHighEntropyToken},
+}
+
+// redactor replaces secrets in file content with RedactedPlaceholder.
+type redactor struct {
+	rules []redactRule
+}
+
+// newRedactor returns a redactor using the built-in rules plus the extra
+// regular expressions, typically taken from the redact_patterns config key.
+func newRedactor(extraPatterns []string) (*redactor, error) {
+	rules := append([]redactRule(nil), builtinRedactRules...)
+	for _, pattern := range extraPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %v", pattern, err)
+		}
+		rules = append(rules, redactRule{re: re})
+	}
+	return &redactor{rules: rules}, nil
+}
+
+// Redact returns text with every secret replaced and the number of replacements.
+func (r *redactor) Redact(text string) (string, int) {
+	total := 0
+	for _, rule := range r.rules {
+		var count int
+		text, count = rule.apply(text)
+		total += count
+	}
+	return text, total
+}
+
+// apply replaces the matches of a single rule.
+func (rule redactRule) apply(text string) (string, int) {
+	var b strings.Builder
+	count, last := 0, 0
+	for _, match := range rule.re.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[0], match[1]
+		if len(match) >= 4 && match[2] >= 0 {
+			start, end = match[2], match[3]
+		}
+		if rule.check != nil && !rule.check(text[start:end]) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(RedactedPlaceholder)
+		last = end
+		count++
+	}
+	if count == 0 {
+		return text, 0
+	}
+	b.WriteString(text[last:])
+	return b.String(), count
+}
+
+// isHighEntropyToken reports whether token mixes letters and digits and has
+// enough entropy to look like a generated key rather than an identifier.
+func isHighEntropyToken(token string) bool {
+	if !strings.ContainsAny(token, "0123456789") || strings.Trim(token, "0123456789") == "" {
+		return false
+	}
+	return shannonEntropy(token) >= minTokenEntropy
+}
+
+// shannonEntropy returns the entropy of s in bits per byte.
+func shannonEntropy(s string) float64 {
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / float64(len(s))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// isSecretFile reports whether the base name of path matches any of the
+// filepath.Match patterns.
+func isSecretFile(path string, patterns []string) bool {
+	name := filepath.Base(path)
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
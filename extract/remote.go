@@ -0,0 +1,65 @@
+package extract
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultFetchTimeout bounds how long fetching a single remote file may take.
+const DefaultFetchTimeout = 30 * time.Second
+
+// MaxFetchSize caps the bytes read from a remote file's response; a larger
+// response fails instead of being buffered whole.
+const MaxFetchSize = 16 << 20
+
+// isRemote reports whether a -files path is an http or https URL.
+func isRemote(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// fetchRemote downloads a file passed to -files by URL. The query string and
+// fragment are left out of the header path, since they may hold tokens and
+// would hide the file extension used to detect the language.
+func fetchRemote(ctx context.Context, rawURL string, timeout time.Duration) (sourceFile, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return sourceFile{}, fmt.Errorf("invalid URL: %v", err)
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return sourceFile{}, fmt.Errorf("failed to create request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return sourceFile{}, fmt.Errorf("failed to fetch: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return sourceFile{}, fmt.Errorf("failed to fetch: unexpected status %s", resp.Status)
+	}
+	if resp.ContentLength > MaxFetchSize {
+		return sourceFile{}, fmt.Errorf("failed to fetch: response of %d bytes is larger than %d bytes", resp.ContentLength, MaxFetchSize)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, MaxFetchSize+1))
+	if err != nil {
+		return sourceFile{}, fmt.Errorf("failed to read response: %v", err)
+	}
+	if len(content) > MaxFetchSize {
+		return sourceFile{}, fmt.Errorf("failed to fetch: response is larger than %d bytes", MaxFetchSize)
+	}
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	path := u.Scheme + "://" + u.Host + u.Path
+	return sourceFile{Path: path, Content: content, ModTime: modTime, InMemory: true}, nil
+}
@@ -0,0 +1,60 @@
+package extract
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchRemote(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/main.go":
+			w.Write([]byte("package main\n"))
+		case "/large": // Sent in chunks, so only the bytes read reveal the size
+			chunk := make([]byte, 1<<20)
+			for written := 0; written <= MaxFetchSize; written += len(chunk) {
+				if _, err := w.Write(chunk); err != nil {
+					return
+				}
+			}
+		case "/large-declared":
+			w.Header().Set("Content-Length", "1000000000")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		wantPath string
+		want     string
+		wantErr  string
+	}{
+		{name: "file", path: "/main.go?token=secret", wantPath: server.URL + "/main.go", want: "package main\n"},
+		{name: "not found", path: "/missing.go", wantErr: "404"},
+		{name: "too large", path: "/large", wantErr: "larger than"},
+		{name: "declared too large", path: "/large-declared", wantErr: "larger than"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fetchRemote(context.Background(), server.URL+tt.path, 0)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("fetchRemote(%s) error = %v, want it to mention %q", tt.path, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Path != tt.wantPath || string(got.Content) != tt.want || !got.InMemory {
+				t.Errorf("fetchRemote(%s) = %q, %q, want %q, %q", tt.path, got.Path, got.Content, tt.wantPath, tt.want)
+			}
+		})
+	}
+}
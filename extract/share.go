@@ -0,0 +1,85 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// SharedConfigs is the portable file written by -export-config and read by
+// -import-config. Folder records where the configurations were exported from;
+// importing always stores them for the folder the command runs in.
+type SharedConfigs struct {
+	Folder    string              `json:"folder,omitempty"`
+	SavedName map[string][]string `json:"saved_name"`
+}
+
+// ExportConfigs writes the configurations saved for currentDir, from both the
+// global and the project config, to path. It returns how many were written.
+func (app *App) ExportConfigs(currentDir, path string) (int, error) {
+	shared := SharedConfigs{Folder: currentDir, SavedName: make(map[string][]string)}
+	for _, config := range app.configLayers() {
+		maps.Copy(shared.SavedName, config.Folders[currentDir].SavedName)
+	}
+	if len(shared.SavedName) == 0 {
+		return 0, fmt.Errorf("no saved configurations found for folder '%s'", currentDir)
+	}
+	data, err := json.MarshalIndent(shared, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal configurations: %v", err)
+	}
+	if err := writeFileAtomic(path, append(data, '\n'), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return len(shared.SavedName), nil
+}
+
+// ImportConfigs reads configurations exported with ExportConfigs from path and
+// saves them for currentDir in the global config. Names that are already saved
+// for the folder are only replaced when force is set. It returns how many
+// configurations were imported.
+func (app *App) ImportConfigs(currentDir, path string, force bool) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	var shared SharedConfigs
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&shared); err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if len(shared.SavedName) == 0 {
+		return 0, fmt.Errorf("%s holds no saved configurations", path)
+	}
+
+	if app.Config.Folders == nil {
+		app.Config.Folders = make(map[string]FolderConfig)
+	}
+	folderConfig := app.Config.Folders[currentDir]
+	if folderConfig.SavedName == nil {
+		folderConfig.SavedName = make(map[string][]string)
+	}
+	if !force {
+		var collisions []string
+		for name := range shared.SavedName {
+			if _, exists := folderConfig.SavedName[name]; exists {
+				collisions = append(collisions, name)
+			}
+		}
+		if len(collisions) > 0 {
+			slices.Sort(collisions)
+			return 0, fmt.Errorf("configurations already saved for folder '%s': %s; pass -force to replace them", currentDir, strings.Join(collisions, ", "))
+		}
+	}
+	maps.Copy(folderConfig.SavedName, shared.SavedName)
+	app.Config.Folders[currentDir] = folderConfig
+	if err := app.saveConfig(); err != nil {
+		return 0, err
+	}
+	return len(shared.SavedName), nil
+}
@@ -0,0 +1,76 @@
+package extract
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExportImportConfigs(t *testing.T) {
+	dir := t.TempDir()
+	newApp := func(name string) *App {
+		t.Helper()
+		app, err := NewApp(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return app
+	}
+	saved := map[string][]string{
+		"go":   {"-files", "main.go", "extract/app.go", "-delimiter", "---"},
+		"docs": {"-files", "README.md", "-wrap-code", "false"},
+	}
+	source := newApp("source.json")
+	for name, args := range saved {
+		if err := source.SaveCurrentConfig("/src/project", name, args); err != nil {
+			t.Fatal(err)
+		}
+	}
+	shared := filepath.Join(dir, "shared.json")
+	if count, err := source.ExportConfigs("/src/project", shared); err != nil || count != 2 {
+		t.Fatalf("ExportConfigs = %d, %v, want 2, nil", count, err)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		target := newApp("target.json")
+		if count, err := target.ImportConfigs("/home/me/project", shared, false); err != nil || count != 2 {
+			t.Fatalf("ImportConfigs = %d, %v, want 2, nil", count, err)
+		}
+		// Reload from disk to check what was saved
+		got := newApp("target.json").SavedConfigs("/home/me/project")
+		for name, args := range saved {
+			if !slices.Equal(got[name], args) {
+				t.Errorf("imported %s = %q, want %q", name, got[name], args)
+			}
+		}
+	})
+
+	t.Run("collision", func(t *testing.T) {
+		target := newApp("collision.json")
+		existing := []string{"-files", "old.go"}
+		if err := target.SaveCurrentConfig("/home/me/project", "go", existing); err != nil {
+			t.Fatal(err)
+		}
+		_, err := target.ImportConfigs("/home/me/project", shared, false)
+		if err == nil || !strings.Contains(err.Error(), "pass -force") {
+			t.Fatalf("ImportConfigs error = %v, want a collision error", err)
+		}
+		if got := newApp("collision.json").SavedConfigs("/home/me/project")["go"]; !slices.Equal(got, existing) {
+			t.Errorf("go after a failed import = %q, want %q", got, existing)
+		}
+
+		if _, err := target.ImportConfigs("/home/me/project", shared, true); err != nil {
+			t.Fatalf("ImportConfigs with force: %v", err)
+		}
+		if got := newApp("collision.json").SavedConfigs("/home/me/project")["go"]; !slices.Equal(got, saved["go"]) {
+			t.Errorf("go after a forced import = %q, want %q", got, saved["go"])
+		}
+	})
+
+	t.Run("nothing to export", func(t *testing.T) {
+		if _, err := source.ExportConfigs("/elsewhere", filepath.Join(dir, "empty.json")); err == nil {
+			t.Error("ExportConfigs for a folder without configurations succeeded, want an error")
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package extract
+
+import (
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// goSignatures returns Go source with the bodies of functions and methods
+// removed, keeping the package clause, imports, type, const and var
+// declarations and the comments attached to them. Function literals, such as
+// a func assigned to a var, keep their bodies.
+func goSignatures(text string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", text, parser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+	comments := ast.NewCommentMap(fset, file, file.Comments)
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			fn.Body = nil
+		}
+	}
+	// Drop the comments that were inside the removed bodies
+	file.Comments = comments.Filter(file).Comments()
+
+	var b strings.Builder
+	if err := format.Node(&b, fset, file); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,98 @@
+package extract
+
+import "testing"
+
+func TestGoSignatures(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "functions and methods",
+			text: `package a
+
+import "fmt"
+
+// T is a type.
+type T struct {
+	N int // Count
+}
+
+// String formats t.
+func (t T) String() string {
+	// Inside the body
+	return fmt.Sprint(t.N)
+}
+
+func helper(a, b int) (int, error) {
+	return a + b, nil
+}
+`,
+			want: `package a
+
+import "fmt"
+
+// T is a type.
+type T struct {
+	N int // Count
+}
+
+// String formats t.
+func (t T) String() string
+
+func helper(a, b int) (int, error)
+`,
+		},
+		{
+			name: "function literals keep their bodies",
+			text: `package a
+
+const Max = 10
+
+var handler = func() int {
+	return Max
+}
+
+func Run() { handler() }
+`,
+			want: `package a
+
+const Max = 10
+
+var handler = func() int {
+	return Max
+}
+
+func Run()
+`,
+		},
+		{
+			name: "generics",
+			text: "package a\n\nfunc Map[T, U any](s []T, f func(T) U) []U {\n\tvar out []U\n\treturn out\n}\n",
+			want: "package a\n\nfunc Map[T, U any](s []T, f func(T) U) []U\n",
+		},
+		{
+			name:    "syntax error",
+			text:    "package a\n\nfunc broken( {\n",
+			wantErr: true,
+		},
+		{
+			name:    "not go",
+			text:    "def main():\n    pass\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := goSignatures(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("goSignatures error = %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("goSignatures =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
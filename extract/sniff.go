@@ -0,0 +1,137 @@
+package extract
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// plaintextLanguage is the fence language of files whose extension is not known.
+const plaintextLanguage = "plaintext"
+
+// contentSniffer guesses the language of a file from its text. It returns an
+// empty string when the content does not look like its language.
+type contentSniffer func(text string) string
+
+// contentSniffers are tried in order by -detect-content for files that would
+// otherwise be fenced as plaintext; the first match wins.
+var contentSniffers = []contentSniffer{
+	sniffShebang,
+	sniffJSON,
+	sniffHTML,
+	sniffXML,
+	sniffYAML,
+}
+
+// detectLanguage returns the language of a file from its extension and, when
+// the extension is unknown and detect is set, from its content.
+func detectLanguage(filePath, text string, detect bool) string {
+	language := languageFor(filePath)
+	if language != plaintextLanguage || !detect {
+		return language
+	}
+	for _, sniff := range contentSniffers {
+		if sniffed := sniff(text); sniffed != "" {
+			return sniffed
+		}
+	}
+	return plaintextLanguage
+}
+
+// shebangLanguages maps interpreter names from a #! line to languages.
+var shebangLanguages = map[string]string{
+	"sh":      "bash",
+	"bash":    "bash",
+	"zsh":     "bash",
+	"fish":    "fish",
+	"python":  "python",
+	"python3": "python",
+	"node":    "javascript",
+	"ruby":    "ruby",
+	"php":     "php",
+}
+
+// sniffShebang recognizes scripts by the interpreter on their #! line,
+// following /usr/bin/env to the program it runs.
+func sniffShebang(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	rest, ok := strings.CutPrefix(line, "#!")
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		// Skip env options such as -S
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = path.Base(field)
+				break
+			}
+		}
+	}
+	return shebangLanguages[interpreter]
+}
+
+// sniffJSON recognizes a JSON object or array.
+func sniffJSON(text string) string {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return ""
+	}
+	if !json.Valid([]byte(trimmed)) {
+		return ""
+	}
+	return "json"
+}
+
+// sniffHTML recognizes documents starting with a doctype or <html> tag.
+func sniffHTML(text string) string {
+	head := strings.ToLower(strings.TrimSpace(text))
+	if strings.HasPrefix(head, "<!doctype html") || strings.HasPrefix(head, "<html") {
+		return "html"
+	}
+	return ""
+}
+
+// sniffXML recognizes documents starting with an XML declaration.
+func sniffXML(text string) string {
+	if strings.HasPrefix(strings.TrimSpace(text), "<?xml") {
+		return "xml"
+	}
+	return ""
+}
+
+// yamlLine matches a YAML mapping key or list item.
+var yamlLine = regexp.MustCompile(`^\s*(- |-$|[\w.-]+:(\s|$))`)
+
+// sniffYAML recognizes a document marker, or content where every line that
+// is not blank or a comment is a mapping key, list item or indented value,
+// with at least two keys.
+func sniffYAML(text string) string {
+	lines := strings.Split(text, "\n")
+	if strings.TrimSpace(lines[0]) == "---" {
+		return "yaml"
+	}
+	keys := 0
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+		case yamlLine.MatchString(line):
+			keys++
+		case line != trimmed:
+			// An indented continuation of a value
+		default:
+			return ""
+		}
+	}
+	if keys < 2 {
+		return ""
+	}
+	return "yaml"
+}
@@ -0,0 +1,39 @@
+package extract
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		text   string
+		detect bool
+		want   string
+	}{
+		{"known extension wins", "a.go", `{"a": 1}`, true, "go"},
+		{"detection off", "data", `{"a": 1}`, false, "plaintext"},
+		{"json object", "data", "{\n  \"a\": [1, 2]\n}\n", true, "json"},
+		{"json array", "data", " [1, 2, 3] ", true, "json"},
+		{"invalid json", "data", "{a: 1}", true, "plaintext"},
+		{"json scalar", "data", "42", true, "plaintext"},
+		{"html doctype", "page", "<!DOCTYPE html>\n<html></html>\n", true, "html"},
+		{"html tag", "page", "\n  <html lang=\"en\">\n", true, "html"},
+		{"html fragment", "page", "<div>hi</div>\n", true, "plaintext"},
+		{"xml", "feed", "<?xml version=\"1.0\"?>\n<feed/>\n", true, "xml"},
+		{"shebang", "run", "#!/bin/sh\necho hi\n", true, "bash"},
+		{"env shebang", "run", "#!/usr/bin/env -S python3 -u\nprint()\n", true, "python"},
+		{"unknown shebang", "run", "#!/usr/bin/awk -f\n", true, "plaintext"},
+		{"yaml", "conf", "name: a\nitems:\n  - b\n", true, "yaml"},
+		{"yaml document marker", "conf", "---\nname: a\n", true, "yaml"},
+		{"single key is not yaml", "notes", "Note: remember this\n", true, "plaintext"},
+		{"prose", "notes", "Hello there.\nSecond line.\n", true, "plaintext"},
+		{"empty", "notes", "", true, "plaintext"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectLanguage(tt.path, tt.text, tt.detect); got != tt.want {
+				t.Errorf("detectLanguage(%q, %q, %t) = %q, want %q", tt.path, tt.text, tt.detect, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package extract
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// bytesPerToken is the rough ratio of bytes to tokens used for estimates.
+const bytesPerToken = 4
+
+// estimateTokens returns a rough token count for text of the given length.
+func estimateTokens(byteCount int) int {
+	return (byteCount + bytesPerToken - 1) / bytesPerToken
+}
+
+// countLines returns the number of lines in text, counting a final line
+// without a trailing newline.
+func countLines(text string) int {
+	lines := strings.Count(text, "\n")
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		lines++
+	}
+	return lines
+}
+
+// formatSummary describes the size of an extraction, e.g.
+// "Extracted 12 files, 3,410 lines, ~14k tokens".
+func formatSummary(files, lines, tokens int) string {
+	fileWord := "files"
+	if files == 1 {
+		fileWord = "file"
+	}
+	lineWord := "lines"
+	if lines == 1 {
+		lineWord = "line"
+	}
+	return fmt.Sprintf("Extracted %s %s, %s %s, ~%s tokens",
+		formatCount(files), fileWord, formatCount(lines), lineWord, formatTokens(tokens))
+}
+
+// formatCount formats n with comma thousands separators.
+func formatCount(n int) string {
+	digits := strconv.Itoa(n)
+	if n < 0 {
+		return "-" + formatCount(-n)
+	}
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return digits
+}
+
+// formatTokens abbreviates token counts of a thousand or more, e.g. 14200 as "14k".
+func formatTokens(tokens int) string {
+	if tokens < 1000 {
+		return strconv.Itoa(tokens)
+	}
+	return formatCount((tokens+500)/1000) + "k"
+}
+
+// languageStats holds the -count totals for one language.
+type languageStats struct {
+	Language string
+	Files    int
+	Bytes    int
+	Lines    int
+}
+
+// countFiles reads files and totals them by language, in language order.
+// Files that cannot be read are returned as errors and left out of the totals,
+// as are files skip reports. With dedupe, a file whose content matches an
+// earlier file counts as a file but adds no bytes or lines.
+func countFiles(files []sourceFile, skip func(filePath string, content []byte) bool, dedupe bool) ([]languageStats, []fileError) {
+	byLanguage := make(map[string]*languageStats)
+	var errs []fileError
+	seen := make(map[string]bool) // Content hashes, for dedupe
+	for _, file := range files {
+		content := file.Content
+		if !file.InMemory {
+			var err error
+			content, err = os.ReadFile(file.Path)
+			if err != nil {
+				errs = append(errs, fileError{Path: file.Path, Err: err})
+				continue
+			}
+		}
+		if skip(file.Path, content) {
+			continue
+		}
+		language := languageFor(file.Path)
+		stats, ok := byLanguage[language]
+		if !ok {
+			stats = &languageStats{Language: language}
+			byLanguage[language] = stats
+		}
+		stats.Files++
+		if dedupe {
+			hash := contentHash(content)
+			if seen[hash] {
+				continue // Extracted as a note naming the earlier file
+			}
+			seen[hash] = true
+		}
+		stats.Bytes += len(content)
+		stats.Lines += countLines(string(content))
+	}
+
+	stats := make([]languageStats, 0, len(byLanguage))
+	for _, languageStats := range byLanguage {
+		stats = append(stats, *languageStats)
+	}
+	slices.SortFunc(stats, func(a, b languageStats) int { return strings.Compare(a.Language, b.Language) })
+	return stats, errs
+}
+
+// formatStats renders -count totals as a table with a row per language and a total row.
+func formatStats(stats []languageStats) string {
+	total := languageStats{Language: "Total"}
+	for _, row := range stats {
+		total.Files += row.Files
+		total.Bytes += row.Bytes
+		total.Lines += row.Lines
+	}
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LANGUAGE\tFILES\tBYTES\tLINES\tTOKENS")
+	for _, row := range append(stats, total) {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t~%s\n", row.Language, formatCount(row.Files),
+			formatCount(row.Bytes), formatCount(row.Lines), formatCount(estimateTokens(row.Bytes)))
+	}
+	w.Flush()
+	return b.String()
+}
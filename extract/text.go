@@ -0,0 +1,205 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// trimBlankLines trims trailing whitespace from every line and collapses runs of
+// blank lines into a single blank line. LF and CRLF line endings are preserved.
+func trimBlankLines(content string) string {
+	var b strings.Builder
+	previousBlank := false
+	for len(content) > 0 {
+		line, rest, found := strings.Cut(content, "\n")
+		content = rest
+
+		ending := ""
+		if found {
+			ending = "\n"
+			if strings.HasSuffix(line, "\r") {
+				ending = "\r\n"
+			}
+		}
+
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if line == "" {
+			if previousBlank {
+				continue
+			}
+			previousBlank = true
+		} else {
+			previousBlank = false
+		}
+		b.WriteString(line)
+		b.WriteString(ending)
+	}
+	return b.String()
+}
+
+// normalizeLineEndings converts CRLF and lone CR line endings to LF.
+func normalizeLineEndings(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	return strings.ReplaceAll(content, "\r", "\n")
+}
+
+// readTextArgument returns the flag value itself, or the contents of the file
+// it names when the value uses the @file.txt syntax.
+func readTextArgument(value string) (string, error) {
+	path, isFile := strings.CutPrefix(value, "@")
+	if !isFile {
+		return value, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// withTrailingNewline appends a newline to text unless it already ends with one.
+func withTrailingNewline(text string) string {
+	if strings.HasSuffix(text, "\n") {
+		return text
+	}
+	return text + "\n"
+}
+
+// Byte order marks recognised by decodeText.
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// decodeText converts file content to UTF-8 text. A UTF-8 byte order mark is
+// dropped, content with a UTF-16 byte order mark is transcoded, and any other
+// invalid UTF-8 bytes are replaced with U+FFFD. replaced reports whether
+// invalid bytes were found.
+func decodeText(content []byte) (text string, replaced bool) {
+	switch {
+	case bytes.HasPrefix(content, bomUTF8):
+		content = content[len(bomUTF8):]
+	case bytes.HasPrefix(content, bomUTF16LE):
+		return decodeUTF16(content[len(bomUTF16LE):], binary.LittleEndian), false
+	case bytes.HasPrefix(content, bomUTF16BE):
+		return decodeUTF16(content[len(bomUTF16BE):], binary.BigEndian), false
+	}
+	if utf8.Valid(content) {
+		return string(content), false
+	}
+	return strings.ToValidUTF8(string(content), string(utf8.RuneError)), true
+}
+
+// decodeUTF16 decodes UTF-16 content in the given byte order. A trailing odd
+// byte is ignored.
+func decodeUTF16(content []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(content)/2)
+	for i := range units {
+		units[i] = order.Uint16(content[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// matchWindows keeps only the lines of text matching re, with before lines of
+// context above and after lines below each match. Windows that overlap or
+// touch are merged, and "..." separates the rest. found is false if no line matches.
+func matchWindows(text string, re *regexp.Regexp, before, after int) (windows string, found bool) {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var b strings.Builder
+	end := -1 // End of the last window written, exclusive
+	for i, line := range lines {
+		if !re.MatchString(strings.TrimRight(line, "\r\n")) {
+			continue
+		}
+		start := max(i-before, 0)
+		if start > end {
+			if end >= 0 {
+				b.WriteString("...\n")
+			}
+		} else {
+			start = end
+		}
+		stop := min(i+after+1, len(lines))
+		for _, windowLine := range lines[start:max(start, stop)] {
+			b.WriteString(windowLine)
+		}
+		end = max(end, stop)
+		found = true
+	}
+	return b.String(), found
+}
+
+// Thresholds used by isLikelyMinified.
+const (
+	minifiedMinSize     = 512  // Smaller files are cheap enough to keep either way
+	minifiedAvgLineLen  = 300  // Average bytes per line above which code looks minified
+	minifiedLongLineLen = 5000 // A single line this long marks the file as minified
+)
+
+// isLikelyMinified reports whether content looks like minified code or data,
+// which packs everything onto a few very long lines.
+func isLikelyMinified(content []byte) bool {
+	if len(content) < minifiedMinSize {
+		return false
+	}
+	lines, longest, current := 0, 0, 0
+	for _, c := range content {
+		if c == '\n' {
+			lines++
+			longest = max(longest, current)
+			current = 0
+			continue
+		}
+		current++
+	}
+	if current > 0 {
+		lines++
+		longest = max(longest, current)
+	}
+	return longest >= minifiedLongLineLen || len(content)/lines > minifiedAvgLineLen
+}
+
+// truncateMiddle shortens text longer than maxBytes to its first and last
+// parts around a marker naming how many bytes were dropped. headRatio is the
+// share of maxBytes kept from the start. Both parts end on line boundaries
+// when a line break is available, so lines are never cut in half.
+func truncateMiddle(text string, maxBytes int, headRatio float64) string {
+	if maxBytes <= 0 || len(text) <= maxBytes {
+		return text
+	}
+	headLen := int(float64(maxBytes) * headRatio)
+	tailLen := maxBytes - headLen
+
+	head := text[:headLen]
+	if i := strings.LastIndexByte(head, '\n'); i >= 0 {
+		head = head[:i+1]
+	}
+	head = strings.ToValidUTF8(head, "")
+
+	tailStart := len(text) - tailLen
+	tail := text[tailStart:]
+	if tailStart > 0 && text[tailStart-1] != '\n' {
+		if i := strings.IndexByte(tail, '\n'); i >= 0 && i+1 < len(tail) {
+			tail = tail[i+1:]
+		}
+	}
+	tail = strings.ToValidUTF8(tail, "")
+
+	dropped := len(text) - len(head) - len(tail)
+	if !strings.HasSuffix(head, "\n") && head != "" {
+		head += "\n"
+	}
+	return fmt.Sprintf("%s... [truncated %d bytes] ...\n%s", head, max(dropped, 0), tail)
+}
@@ -0,0 +1,171 @@
+package extract
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTrimBlankLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"empty", "", ""},
+		{"unchanged", "a\nb\n", "a\nb\n"},
+		{"trailing whitespace", "a  \nb\t\n", "a\nb\n"},
+		{"blank run", "a\n\n\n\nb\n", "a\n\nb\n"},
+		{"whitespace-only lines", "a\n  \n\t\n b\n", "a\n\n b\n"},
+		{"crlf", "a \r\n\r\n\r\nb\r\n", "a\r\n\r\nb\r\n"},
+		{"mixed endings", "a\r\n\n\r\nb\n", "a\r\n\nb\n"},
+		{"no trailing newline", "a\n\n\nb  ", "a\n\nb"},
+		{"crlf without trailing newline", "a\r\n\r\n\r\nb\r", "a\r\n\r\nb"},
+		{"trailing blank lines", "a\n\n\n", "a\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trimBlankLines(tt.content); got != tt.want {
+				t.Errorf("trimBlankLines(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeLineEndings(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"empty", "", ""},
+		{"lf", "a\nb\n", "a\nb\n"},
+		{"crlf", "a\r\nb\r\n", "a\nb\n"},
+		{"lone cr", "a\rb\r", "a\nb\n"},
+		{"mixed", "a\r\nb\rc\n", "a\nb\nc\n"},
+		{"cr before crlf", "a\r\r\nb", "a\n\nb"},
+		{"no trailing newline", "a\r\nb", "a\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeLineEndings(tt.content); got != tt.want {
+				t.Errorf("normalizeLineEndings(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      []byte
+		want         string
+		wantReplaced bool
+	}{
+		{"empty", nil, "", false},
+		{"plain", []byte("héllo\n"), "héllo\n", false},
+		{"utf-8 bom", []byte("\xef\xbb\xbfhello"), "hello", false},
+		{"bom only", []byte("\xef\xbb\xbf"), "", false},
+		{"bom in the middle", []byte("a\xef\xbb\xbfb"), "a\ufeffb", false},
+		{"utf-16le", []byte("\xff\xfeh\x00\xe9\x00\n\x00"), "hé\n", false},
+		{"utf-16be", []byte("\xfe\xff\x00h\x00\xe9\x00\n"), "hé\n", false},
+		{"utf-16 odd byte", []byte("\xff\xfeh\x00i"), "h", false},
+		{"invalid byte", []byte("caf\xe9 ok\n"), "caf\ufffd ok\n", true},
+		{"invalid run", []byte("a\xff\xfe\xfdb"), "a\ufffdb", true},
+		{"truncated rune", []byte("a\xe2\x82"), "a\ufffd", true},
+		{"bom then invalid", []byte("\xef\xbb\xbfa\xffb"), "a\ufffdb", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, replaced := decodeText(tt.content)
+			if got != tt.want || replaced != tt.wantReplaced {
+				t.Errorf("decodeText(%q) = %q, %t, want %q, %t", tt.content, got, replaced, tt.want, tt.wantReplaced)
+			}
+		})
+	}
+}
+
+func TestMatchWindows(t *testing.T) {
+	const text = "1\n2 x\n3\n4\n5\n6 x\n7\n8\n9\n10 x\n"
+	tests := []struct {
+		name          string
+		text          string
+		pattern       string
+		before, after int
+		want          string
+		wantFound     bool
+	}{
+		{"no match", text, "y", 1, 1, "", false},
+		{"matches only", text, "x", 0, 0, "2 x\n...\n6 x\n...\n10 x\n", true},
+		{"separate windows", text, "x", 1, 1, "1\n2 x\n3\n...\n5\n6 x\n7\n...\n9\n10 x\n", true},
+		{"touching windows merge", text, "x", 0, 3, "2 x\n3\n4\n5\n6 x\n7\n8\n9\n10 x\n", true},
+		{"overlapping windows merge", text, "x", 2, 2, "1\n2 x\n3\n4\n5\n6 x\n7\n8\n9\n10 x\n", true},
+		{"gap of one line", text, "x", 1, 2, "1\n2 x\n3\n4\n5\n6 x\n7\n8\n9\n10 x\n", true},
+		{"adjacent matches", "a\nx\nx\nb\nc\n", "x", 0, 1, "x\nx\nb\n", true},
+		{"context past the edges", "x\n", "x", 5, 5, "x\n", true},
+		{"no trailing newline", "a\nb\nx", "x", 1, 1, "b\nx", true},
+		{"crlf", "a\r\nx\r\nb\r\nc\r\n", "x$", 0, 1, "x\r\nb\r\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := matchWindows(tt.text, regexp.MustCompile(tt.pattern), tt.before, tt.after)
+			if got != tt.want || found != tt.wantFound {
+				t.Errorf("matchWindows(%q, %q, %d, %d) = %q, %t, want %q, %t", tt.text, tt.pattern, tt.before, tt.after, got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestIsLikelyMinified(t *testing.T) {
+	code := strings.Repeat("function add(a, b) {\n  return a + b;\n}\n", 40)
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"empty", "", false},
+		{"small single line", strings.Repeat("x", minifiedMinSize-1), false},
+		{"regular code", code, false},
+		{"minified bundle", strings.Repeat("var a=1;", 1000), true},
+		{"minified with trailing newline", strings.Repeat("var a=1;", 1000) + "\n", true},
+		{"one very long line", code + strings.Repeat("x", minifiedLongLineLen) + "\n" + code, true},
+		{"line just under the limit", code + strings.Repeat("x", minifiedLongLineLen-1) + "\n" + code, false},
+		{"long average line length", strings.Repeat(strings.Repeat("y", minifiedAvgLineLen+10)+"\n", 4), true},
+		{"blank lines", strings.Repeat("\n", minifiedMinSize), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isLikelyMinified([]byte(tt.content)); got != tt.want {
+				t.Errorf("isLikelyMinified(%d bytes) = %t, want %t", len(tt.content), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateMiddle(t *testing.T) {
+	const lines = "line 1\nline 2\nline 3\nline 4\nline 5\nline 6\nline 7\nline 8\n" // 56 bytes
+	tests := []struct {
+		name      string
+		text      string
+		maxBytes  int
+		headRatio float64
+		want      string
+	}{
+		{"no limit", lines, 0, 0.5, lines},
+		{"fits", lines, len(lines), 0.5, lines},
+		{"even split", lines, 28, 0.5, "line 1\nline 2\n... [truncated 28 bytes] ...\nline 7\nline 8\n"},
+		{"head only", lines, 28, 1, "line 1\nline 2\nline 3\nline 4\n... [truncated 28 bytes] ...\n"},
+		{"tail only", lines, 28, 0, "... [truncated 28 bytes] ...\nline 5\nline 6\nline 7\nline 8\n"},
+		{"mostly head", lines, 28, 0.75, "line 1\nline 2\nline 3\n... [truncated 28 bytes] ...\nline 8\n"},
+		{"cuts back to line ends", lines, 20, 0.5, "line 1\n... [truncated 42 bytes] ...\nline 8\n"},
+		{"single long line", "abcdefghijklmnopqrstuvwxyz", 10, 0.5, "abcde\n... [truncated 16 bytes] ...\nvwxyz"},
+		{"multi-byte runes", "ééééé|ééééé", 9, 0.5, "éé\n... [truncated 13 bytes] ...\néé"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateMiddle(tt.text, tt.maxBytes, tt.headRatio); got != tt.want {
+				t.Errorf("truncateMiddle(%q, %d, %g) = %q, want %q", tt.text, tt.maxBytes, tt.headRatio, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package extract
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// treeNode is a directory or file in the rendered file tree.
+type treeNode struct {
+	name     string
+	children map[string]*treeNode
+}
+
+// renderTree renders the file paths as an ASCII directory tree grouped by directory.
+func renderTree(paths []string) string {
+	root := &treeNode{children: make(map[string]*treeNode)}
+	for _, path := range paths {
+		parts := strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+		if parts[0] == "" {
+			parts[0] = "/" // Absolute paths hang off the filesystem root
+		}
+		node := root
+		for _, part := range parts {
+			child, exists := node.children[part]
+			if !exists {
+				child = &treeNode{name: part, children: make(map[string]*treeNode)}
+				node.children[part] = child
+			}
+			node = child
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(".\n")
+	writeTreeChildren(&b, root, "")
+	return b.String()
+}
+
+// writeTreeChildren writes the children of node, directories first and then by name.
+func writeTreeChildren(b *strings.Builder, node *treeNode, indent string) {
+	children := make([]*treeNode, 0, len(node.children))
+	for _, child := range node.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		iDir, jDir := len(children[i].children) > 0, len(children[j].children) > 0
+		if iDir != jDir {
+			return iDir
+		}
+		return children[i].name < children[j].name
+	})
+
+	for i, child := range children {
+		branch, nextIndent := "|-- ", indent+"|   "
+		if i == len(children)-1 {
+			branch, nextIndent = "`-- ", indent+"    "
+		}
+		name := child.name
+		if len(child.children) > 0 && name != "/" {
+			name += "/"
+		}
+		b.WriteString(indent + branch + name + "\n")
+		writeTreeChildren(b, child, nextIndent)
+	}
+}
+
+// formatTOC renders a numbered list of paths for -toc.
+func formatTOC(paths []string) string {
+	var b strings.Builder
+	b.WriteString("Files:\n")
+	width := len(strconv.Itoa(len(paths)))
+	for i, path := range paths {
+		fmt.Fprintf(&b, "%*d. %s\n", width, i+1, path)
+	}
+	return b.String()
+}
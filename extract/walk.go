@@ -0,0 +1,158 @@
+package extract
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// walkOptions controls how directories passed to -files are expanded.
+type walkOptions struct {
+	MaxDepth       int      // Deepest subdirectory level to read; 0 is the directory itself and -1 is unlimited
+	ExcludeDirs    []string // Directory names that are never entered
+	FollowSymlinks bool     // Follow symlinked files and directories instead of skipping them
+	Hidden         bool     // Read dotfiles and dot-directories other than .git
+
+	Verbosef func(format string, args ...any) // Reports skipped symlinks and directories; may be nil
+}
+
+// expandDirs replaces every directory in paths with the regular files found
+// under it. Other paths are returned unchanged, in their original position.
+// Entries that could not be read are returned as errors.
+func expandDirs(paths []string, options walkOptions) ([]string, []fileError) {
+	var expanded []string
+	var errs []fileError
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			expanded = append(expanded, path)
+			continue
+		}
+		files, walkErrs := walkDir(path, options)
+		expanded = append(expanded, files...)
+		errs = append(errs, walkErrs...)
+	}
+	return expanded, errs
+}
+
+// walkDir returns the regular files under root in lexical order, skipping
+// .git, hidden entries unless options.Hidden is set, excluded directories and
+// anything deeper than options.MaxDepth.
+func walkDir(root string, options walkOptions) ([]string, []fileError) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, []fileError{{Path: root, Err: err}}
+	}
+	w := &dirWalker{options: options, visited: []os.FileInfo{info}}
+	w.walk(root, 0)
+	return w.files, w.errs
+}
+
+// dirWalker collects files for walkDir. Directories it has entered are kept
+// in visited so symlinks pointing back up the tree cannot cause a loop.
+type dirWalker struct {
+	options walkOptions
+	visited []os.FileInfo
+	files   []string
+	errs    []fileError
+}
+
+// walk reads dir, which is depth levels below the root.
+func (w *dirWalker) walk(dir string, depth int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		w.errs = append(w.errs, fileError{Path: dir, Err: err})
+		return
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		mode := entry.Type()
+
+		if entry.Name() == ".git" {
+			continue
+		}
+		if !w.options.Hidden && strings.HasPrefix(entry.Name(), ".") {
+			w.verbosef("Skipping %s: hidden, pass -hidden to include it", path)
+			continue
+		}
+
+		if mode&fs.ModeSymlink != 0 {
+			if !w.options.FollowSymlinks {
+				w.verbosef("Skipping %s: symlink, pass -follow-symlinks to follow it", path)
+				continue
+			}
+			target, err := os.Stat(path)
+			if err != nil {
+				w.errs = append(w.errs, fileError{Path: path, Err: fmt.Errorf("failed to follow symlink: %v", err)})
+				continue
+			}
+			mode = target.Mode().Type()
+		}
+
+		switch {
+		case mode.IsDir():
+			if slices.Contains(w.options.ExcludeDirs, entry.Name()) {
+				continue
+			}
+			if w.options.MaxDepth >= 0 && depth+1 > w.options.MaxDepth {
+				continue
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				w.errs = append(w.errs, fileError{Path: path, Err: err})
+				continue
+			}
+			if w.entered(info) {
+				w.verbosef("Skipping %s: directory was already read", path)
+				continue
+			}
+			w.visited = append(w.visited, info)
+			w.walk(path, depth+1)
+		case mode.IsRegular():
+			w.files = append(w.files, path)
+		}
+	}
+}
+
+// entered reports whether the directory described by info was already read.
+// os.SameFile compares device and inode numbers on Unix.
+func (w *dirWalker) entered(info os.FileInfo) bool {
+	for _, visited := range w.visited {
+		if os.SameFile(visited, info) {
+			return true
+		}
+	}
+	return false
+}
+
+// verbosef reports a walk decision when -verbose is set.
+func (w *dirWalker) verbosef(format string, args ...any) {
+	if w.options.Verbosef != nil {
+		w.options.Verbosef(format, args...)
+	}
+}
+
+// ListFiles returns the files under dir that an extraction of dir would
+// consider: hidden entries and .git are skipped, as are files matched by
+// .gitignore or .extractignore. Paths are relative to dir.
+func (app *App) ListFiles(dir string) ([]string, error) {
+	files, errs := walkDir(dir, walkOptions{MaxDepth: -1})
+	if len(files) == 0 && len(errs) > 0 {
+		return nil, errs[0]
+	}
+	ignores := app.ignoreRules(dir, true, false)
+	var listed []string
+	for _, file := range files {
+		if ignoredBy, err := ignores.Match(file); err != nil || ignoredBy != "" {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, file); err == nil {
+			file = rel
+		}
+		listed = append(listed, file)
+	}
+	return listed, nil
+}
@@ -0,0 +1,265 @@
+package extract
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// walkRel walks root and returns the files found relative to it, with slashes.
+func walkRel(t *testing.T, root string, options walkOptions) []string {
+	t.Helper()
+	files, errs := walkDir(root, options)
+	if len(errs) > 0 {
+		t.Fatalf("walkDir(%s) errors: %v", root, errs)
+	}
+	var rel []string
+	for _, file := range files {
+		path, err := filepath.Rel(root, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rel = append(rel, filepath.ToSlash(path))
+	}
+	return rel
+}
+
+func TestWalkDirExcludeDirs(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.go":                      "",
+		"vendor/lib.go":                "",
+		"pkg/a.go":                     "",
+		"pkg/vendor/lib.go":            "",
+		"pkg/sub/node_modules/x.js":    "",
+		"pkg/sub/b.go":                 "",
+		"pkg/vendored/c.go":            "",
+		"node_modules/vendor/deep.js":  "",
+		"web/node_modules/pkg/main.js": "",
+	})
+
+	tests := []struct {
+		name    string
+		exclude []string
+		want    []string
+	}{
+		{
+			name: "none",
+			want: []string{
+				"main.go", "node_modules/vendor/deep.js", "pkg/a.go", "pkg/sub/b.go",
+				"pkg/sub/node_modules/x.js", "pkg/vendor/lib.go", "pkg/vendored/c.go",
+				"vendor/lib.go", "web/node_modules/pkg/main.js",
+			},
+		},
+		{
+			name:    "vendor at any depth",
+			exclude: []string{"vendor"},
+			want: []string{
+				"main.go", "pkg/a.go", "pkg/sub/b.go",
+				"pkg/sub/node_modules/x.js", "pkg/vendored/c.go", "web/node_modules/pkg/main.js",
+			},
+		},
+		{
+			name:    "several names",
+			exclude: []string{"vendor", "node_modules"},
+			want:    []string{"main.go", "pkg/a.go", "pkg/sub/b.go", "pkg/vendored/c.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := walkRel(t, root, walkOptions{MaxDepth: -1, ExcludeDirs: tt.exclude})
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("walkDir with ExcludeDirs %q = %q, want %q", tt.exclude, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcludedDir(t *testing.T) {
+	tests := []struct {
+		path  string
+		names []string
+		want  string
+	}{
+		{"pkg/a.go", []string{"vendor"}, ""},
+		{"vendor/a.go", []string{"vendor"}, "vendor"},
+		{"pkg/sub/vendor/a.go", []string{"vendor"}, "vendor"},
+		{"pkg/vendored/a.go", []string{"vendor"}, ""},
+		{"pkg/vendor", []string{"vendor"}, ""},
+		{"web/node_modules/x/a.js", []string{"vendor", "node_modules"}, "node_modules"},
+		{"vendor/a.go", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := excludedDir(filepath.FromSlash(tt.path), tt.names); got != tt.want {
+				t.Errorf("excludedDir(%q, %q) = %q, want %q", tt.path, tt.names, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWalkDirMaxDepth(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"a.go":       "",
+		"b/b.go":     "",
+		"b/c/c.go":   "",
+		"b/c/d/d.go": "",
+	})
+
+	tests := []struct {
+		maxDepth int
+		want     []string
+	}{
+		{-1, []string{"a.go", "b/b.go", "b/c/c.go", "b/c/d/d.go"}},
+		{0, []string{"a.go"}},
+		{1, []string{"a.go", "b/b.go"}},
+		{2, []string{"a.go", "b/b.go", "b/c/c.go"}},
+		{10, []string{"a.go", "b/b.go", "b/c/c.go", "b/c/d/d.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.maxDepth), func(t *testing.T) {
+			if got := walkRel(t, root, walkOptions{MaxDepth: tt.maxDepth}); !slices.Equal(got, tt.want) {
+				t.Errorf("walkDir with MaxDepth %d = %q, want %q", tt.maxDepth, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandDirsMaxDepthPerRoot(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"a.go":     "",
+		"b/b.go":   "",
+		"b/c/c.go": "",
+	})
+
+	// Depth counts from each directory given, so b/c/c.go is one level below b
+	files, errs := expandDirs([]string{root, filepath.Join(root, "b")}, walkOptions{MaxDepth: 1})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	want := []string{
+		filepath.Join(root, "a.go"), filepath.Join(root, "b", "b.go"),
+		filepath.Join(root, "b", "b.go"), filepath.Join(root, "b", "c", "c.go"),
+	}
+	if !slices.Equal(files, want) {
+		t.Errorf("expandDirs = %q, want %q", files, want)
+	}
+}
+
+func TestWalkDirSymlinks(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"a/a.go":       "",
+		"a/b/b.go":     "",
+		"other/out.go": "",
+	})
+	links := map[string]string{
+		"a/b/loop":   "..",       // Points back at a parent
+		"a/self":     ".",        // Points at its own directory
+		"a/other":    "../other", // Points outside the walked tree
+		"a/link.go":  "a.go",     // A symlinked file
+		"a/dangling": "missing",  // Only reported when followed
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Skipf("symlinks are not supported: %v", err)
+		}
+	}
+	walkRoot := filepath.Join(root, "a")
+
+	t.Run("skipped", func(t *testing.T) {
+		got := walkRel(t, walkRoot, walkOptions{MaxDepth: -1})
+		want := []string{"a.go", "b/b.go"}
+		if !slices.Equal(got, want) {
+			t.Errorf("walkDir = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("followed", func(t *testing.T) {
+		files, errs := walkDir(walkRoot, walkOptions{MaxDepth: -1, FollowSymlinks: true})
+		var got []string
+		for _, file := range files {
+			rel, _ := filepath.Rel(walkRoot, file)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		want := []string{"a.go", "b/b.go", "link.go", "other/out.go"}
+		if !slices.Equal(got, want) {
+			t.Errorf("walkDir = %q, want %q", got, want)
+		}
+		if len(errs) != 1 || errs[0].Path != filepath.Join(walkRoot, "dangling") {
+			t.Errorf("walkDir errors = %v, want one for the dangling link", errs)
+		}
+	})
+}
+
+func TestWalkDirHidden(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.go":             "",
+		".env":                "",
+		".github/ci.yml":      "",
+		"pkg/.hidden.go":      "",
+		"pkg/a.go":            "",
+		".git/config":         "",
+		"pkg/.git/HEAD":       "",
+		".config/.nested/x.y": "",
+	})
+
+	tests := []struct {
+		hidden bool
+		want   []string
+	}{
+		{false, []string{"main.go", "pkg/a.go"}},
+		{true, []string{".config/.nested/x.y", ".env", ".github/ci.yml", "main.go", "pkg/.hidden.go", "pkg/a.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("hidden=%t", tt.hidden), func(t *testing.T) {
+			if got := walkRel(t, root, walkOptions{MaxDepth: -1, Hidden: tt.hidden}); !slices.Equal(got, tt.want) {
+				t.Errorf("walkDir with Hidden %t = %q, want %q", tt.hidden, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractHiddenSecrets(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":   "package main\n",
+		".env":      "TOKEN=abc\n",
+		".tool.yml": "level: 1\n",
+	})
+
+	tests := []struct {
+		args []string
+		want []string // Headers expected in the output
+		skip []string // Headers expected to be missing
+	}{
+		{nil, []string{"main.go"}, []string{".env", ".tool.yml"}},
+		{[]string{"-hidden"}, []string{"main.go", ".tool.yml"}, []string{".env"}},
+		{[]string{"-hidden", "-include-secrets"}, []string{"main.go", ".tool.yml", ".env"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.args), func(t *testing.T) {
+			got, err := extractIn(t, dir, append([]string{"-files", dir, "-quiet"}, tt.args...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(got, "\n")
+			for _, header := range tt.want {
+				if !slices.Contains(lines, header) {
+					t.Errorf("output is missing %s:\n%s", header, got)
+				}
+			}
+			for _, header := range tt.skip {
+				if slices.Contains(lines, header) {
+					t.Errorf("output includes %s:\n%s", header, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Values accepted by -format.
+const (
+	FormatMarkdown = "markdown" // Headers, code fences and delimiters
+	FormatXML      = "xml"      // One <document> element per file
+)
+
+var formats = []string{FormatMarkdown, FormatXML}
+
+// renderXML lays out data as a <documents> element with a <document> per
+// file. With lineNumbers, each document carries start_line and end_line
+// attributes and its content is split into numbered <line> elements, so
+// exact lines can be cited. With separateExecOutput, executable output is
+// collected in <exec_output> elements after the documents instead of inside
+// them. Files listed without their content are empty <document> elements.
+func renderXML(data documentData, lineNumbers, separateExecOutput bool) string {
+	var b strings.Builder
+	b.WriteString("<documents>\n")
+	if data.Prepend != "" {
+		writeXMLElement(&b, "prepend", "", data.Prepend)
+	}
+	if data.TOC != "" {
+		writeXMLElement(&b, "toc", "", data.TOC)
+	}
+	if data.Tree != "" {
+		writeXMLElement(&b, "tree", "", data.Tree)
+	}
+	for i, file := range data.Files {
+		fmt.Fprintf(&b, `<document index="%d" path="%s" language="%s"`, i+1, xmlEscape(file.Path), xmlEscape(file.Language))
+		if file.Omitted {
+			fmt.Fprintf(&b, ` omitted="true" size="%d"/>`+"\n", file.Size)
+			continue
+		}
+		if file.IdenticalTo != "" {
+			fmt.Fprintf(&b, ` identical_to="%s"/>`+"\n", xmlEscape(file.IdenticalTo))
+			continue
+		}
+		if !lineNumbers {
+			b.WriteString(">\n")
+			writeXMLElement(&b, "content", "", file.Content)
+		} else {
+			lines := splitDiffLines(file.Content)
+			fmt.Fprintf(&b, ` start_line="%d" end_line="%d">`+"\n", min(1, len(lines)), len(lines))
+			b.WriteString("<content>\n")
+			for n, line := range lines {
+				fmt.Fprintf(&b, `<line number="%d">%s</line>`+"\n", n+1, xmlEscape(line))
+			}
+			b.WriteString("</content>\n")
+		}
+		if file.ExecOutput != "" && !separateExecOutput {
+			writeXMLElement(&b, "exec_output", "", file.ExecOutput)
+		}
+		b.WriteString("</document>\n")
+	}
+	if separateExecOutput {
+		for _, file := range data.Files {
+			if file.ExecOutput != "" {
+				writeXMLElement(&b, "exec_output", fmt.Sprintf(` path="%s"`, xmlEscape(file.Path)), file.ExecOutput)
+			}
+		}
+	}
+	for _, diff := range data.Diffs {
+		writeXMLElement(&b, "diff", fmt.Sprintf(` old="%s" new="%s"`, xmlEscape(diff.Old), xmlEscape(diff.New)), diff.Diff)
+	}
+	for _, batch := range data.Batches {
+		writeXMLElement(&b, "batch_output", fmt.Sprintf(` command="%s"`, xmlEscape(batch.Command)), batch.Output)
+	}
+	if data.Append != "" {
+		writeXMLElement(&b, "append", "", data.Append)
+	}
+	b.WriteString("</documents>\n")
+	return b.String()
+}
+
+// insertXMLSummary adds the -summary line as a <summary> element at the end
+// of the root element of document, keeping it well-formed.
+func insertXMLSummary(document, summary string) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimSuffix(document, "</documents>\n"))
+	writeXMLElement(&b, "summary", "", summary)
+	b.WriteString("</documents>\n")
+	return b.String()
+}
+
+// writeXMLElement writes text, escaped, as the content of an element named
+// name with the given attributes, each element tag on its own line.
+func writeXMLElement(b *strings.Builder, name, attrs, text string) {
+	fmt.Fprintf(b, "<%s%s>\n%s\n</%s>\n", name, attrs, xmlEscape(strings.TrimSuffix(text, "\n")), name)
+}
+
+// xmlEscaper escapes the characters that are special in XML text and
+// double-quoted attribute values.
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+// xmlEscape escapes text for use in XML character data and attribute values.
+// Unlike xml.EscapeText it keeps newlines readable; characters XML does not
+// allow at all are replaced with U+FFFD.
+func xmlEscape(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if isXMLChar(r) {
+			return r
+		}
+		return unicode.ReplacementChar
+	}, text)
+	return xmlEscaper.Replace(text)
+}
+
+// isXMLChar reports whether r is in the XML 1.0 Char production.
+func isXMLChar(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' ||
+		(r >= 0x20 && r <= 0xD7FF) ||
+		(r >= 0xE000 && r <= 0xFFFD) ||
+		(r >= 0x10000 && r <= unicode.MaxRune)
+}
@@ -0,0 +1,119 @@
+package extract
+
+import (
+	"encoding/xml"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// xmlOutput mirrors the -format xml layout for decoding in tests.
+type xmlOutput struct {
+	XMLName   xml.Name `xml:"documents"`
+	TOC       string   `xml:"toc"`
+	Tree      string   `xml:"tree"`
+	Documents []struct {
+		Path        string `xml:"path,attr"`
+		Omitted     bool   `xml:"omitted,attr"`
+		IdenticalTo string `xml:"identical_to,attr"`
+		StartLine   int    `xml:"start_line,attr"`
+		Content     struct {
+			Text  string   `xml:",chardata"`
+			Lines []string `xml:"line"` // Set with -line-numbers
+		} `xml:"content"`
+	} `xml:"document"`
+	ExecOutputs []struct {
+		Path string `xml:"path,attr"`
+		Text string `xml:",chardata"`
+	} `xml:"exec_output"`
+	Diffs []struct {
+		Old  string `xml:"old,attr"`
+		New  string `xml:"new,attr"`
+		Text string `xml:",chardata"`
+	} `xml:"diff"`
+	Summary string `xml:"summary"`
+}
+
+func TestExtractXML(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go":     "package a\n\n// a < b && c > d\nconst s = \"]]>\\x01\"\n",
+		"copy.go":  "package a\n\n// a < b && c > d\nconst s = \"]]>\\x01\"\n",
+		"data.csv": "a,b\n",
+		"ctrl.txt": "bell\x07 and <tag>\n",
+		"old.txt":  "x\n",
+		"new.txt":  "y & z\n",
+	})
+	path := func(name string) string { return filepath.Join(dir, name) }
+	args := []string{
+		"-files", path("a.go"), path("copy.go"), path("data.csv"), path("ctrl.txt"),
+		"-format", FormatXML, "-toc", "-tree", "-summary", "-manifest-pattern", `\.csv$`, "-dedupe-content",
+		"-diff", path("old.txt"), path("new.txt"),
+		"-exec", `sh -c "echo '<ok>'"`, "-exec-output-position", ExecOutputSeparate,
+	}
+
+	for _, lineNumbers := range []bool{false, true} {
+		name := "plain"
+		runArgs := args
+		if lineNumbers {
+			name = "line numbers"
+			runArgs = append(runArgs[:len(runArgs):len(runArgs)], "-line-numbers")
+		}
+		t.Run(name, func(t *testing.T) {
+			got, err := extractIn(t, dir, runArgs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var doc xmlOutput
+			decoder := xml.NewDecoder(strings.NewReader(got))
+			if err := decoder.Decode(&doc); err != nil {
+				t.Fatalf("output is not well-formed XML: %v\n%s", err, got)
+			}
+			// Nothing may follow the root element
+			if rest := strings.TrimSpace(got[decoder.InputOffset():]); rest != "" {
+				t.Errorf("output continues after </documents>: %q", rest)
+			}
+
+			if !strings.Contains(doc.TOC, "1. a.go") || !strings.Contains(doc.Tree, "data.csv") {
+				t.Errorf("toc %q or tree %q is missing files", doc.TOC, doc.Tree)
+			}
+			if len(doc.Documents) != 4 {
+				t.Fatalf("output has %d documents, want 4:\n%s", len(doc.Documents), got)
+			}
+			content := make([]string, len(doc.Documents))
+			for i, document := range doc.Documents {
+				content[i] = document.Content.Text + strings.Join(document.Content.Lines, "\n")
+			}
+			first, copied, manifest := doc.Documents[0], doc.Documents[1], doc.Documents[2]
+			if !strings.Contains(content[0], "a < b && c > d") || !strings.Contains(content[0], `"]]>`) {
+				t.Errorf("a.go content did not round-trip: %q", content[0])
+			}
+			if lineNumbers && first.StartLine != 1 {
+				t.Errorf("a.go start_line = %d, want 1", first.StartLine)
+			}
+			if copied.IdenticalTo != "a.go" || content[1] != "" {
+				t.Errorf("copy.go = identical_to %q with content %q, want identical_to a.go without content", copied.IdenticalTo, content[1])
+			}
+			if !manifest.Omitted || content[2] != "" {
+				t.Errorf("data.csv = omitted %t with content %q, want omitted without content", manifest.Omitted, content[2])
+			}
+			if !strings.Contains(content[3], "<tag>") {
+				t.Errorf("ctrl.txt content did not round-trip: %q", content[3])
+			}
+			// The duplicate and the manifest file are not run
+			if len(doc.ExecOutputs) != 2 || doc.ExecOutputs[1].Path != "ctrl.txt" || !strings.Contains(doc.ExecOutputs[0].Text, "<ok>") {
+				t.Errorf("exec outputs = %+v, want a.go and ctrl.txt holding <ok>", doc.ExecOutputs)
+			}
+			if len(doc.Diffs) != 1 || !strings.Contains(doc.Diffs[0].Text, "+y & z") {
+				t.Errorf("diffs = %+v, want one adding y & z", doc.Diffs)
+			}
+			if !strings.HasPrefix(strings.TrimSpace(doc.Summary), "Extracted 4 files") {
+				t.Errorf("summary = %q, want it to count 4 files", doc.Summary)
+			}
+		})
+	}
+}